@@ -0,0 +1,187 @@
+package gcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BatchLoaderFunc loads the values of keys missing from the cache, see Cache.GetOrLoadMany.
+// The keys missing from the returned map are left missing from the cache.
+type BatchLoaderFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// groupByShard returns the keys grouped by the index of their shard, each group in the order of keys.
+// The keys which are not hashable are left out.
+func (c *Cache[K, V]) groupByShard(keys []K) map[uint64][]K {
+	groups := make(map[uint64][]K)
+	for _, key := range keys {
+		if !c.hashable(key) {
+			continue
+		}
+		i := c.shardIndex(key)
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+// GetMany reads the entries of the keys, taking the lock of each shard once.
+// The keys not found are missing from the returned map.
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	if !c.begin() {
+		return map[K]V{}
+	}
+	defer c.end()
+	values, _, stale := c.getMany(keys)
+	if c.loader != nil {
+		for _, key := range stale {
+			c.refresh(c.getShard(key), key, c.loader)
+		}
+	}
+	return values
+}
+
+// getMany reads the entries of the keys, returning the keys not found and those due for a refresh.
+func (c *Cache[K, V]) getMany(keys []K) (values map[K]V, missing, stale []K) {
+	values = make(map[K]V, len(keys))
+	for i, group := range c.groupByShard(keys) {
+		missing, stale = c.shards[i].getMany(group, values, missing, stale)
+	}
+	return
+}
+
+// GetOrLoadMany reads the entries of the keys, calling loader once with all the keys not found
+// and storing the loaded values. When loader is nil the missing keys are loaded one by one with
+// the Loader option. The values found are returned along with the loader error.
+func (c *Cache[K, V]) GetOrLoadMany(ctx context.Context, keys []K, loader BatchLoaderFunc[K, V]) (map[K]V, error) {
+	if !c.begin() {
+		return map[K]V{}, ErrClosed
+	}
+	defer c.end()
+	values, missing, stale := c.getMany(keys)
+	if loader == nil {
+		if c.loader == nil {
+			return values, ErrNoLoader
+		}
+		for _, key := range stale {
+			c.refresh(c.getShard(key), key, c.loader)
+		}
+		for _, key := range missing {
+			value, err := c.load(ctx, c.getShard(key), key, c.loader, false)
+			if err != nil {
+				return values, err
+			}
+			values[key] = value
+		}
+		return values, nil
+	}
+	if len(stale) > 0 {
+		c.refreshMany(stale, loader)
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+	loaded, err := loader(ctx, missing)
+	if err != nil {
+		return values, fmt.Errorf("%w: %w", ErrLoaderFailed, err)
+	}
+	c.SetMany(loaded)
+	for key, value := range loaded {
+		values[key] = value
+	}
+	return values, nil
+}
+
+// refreshMany reloads the keys in the background with one loader call, the current values are kept when it fails.
+// As with refresh, the reloaded entries keep their expiration.
+func (c *Cache[K, V]) refreshMany(keys []K, loader BatchLoaderFunc[K, V]) {
+	if !c.begin() {
+		return
+	}
+	go func() {
+		defer c.end()
+		defer c.printPanicStack()
+		loaded, err := loader(context.Background(), keys)
+		if err != nil {
+			c.logger.Warnf("cache %s: refresh %d keys: %v", c.name, len(keys), err)
+			return
+		}
+		for key, value := range loaded {
+			if c.hashable(key) {
+				c.getShard(key).reload(key, value, c.cc.Expiration)
+			}
+		}
+	}()
+}
+
+// SetMany adds the entries, taking the lock of each shard once.
+func (c *Cache[K, V]) SetMany(entries map[K]V) {
+	c.SetManyWithTTL(entries, c.cc.Expiration)
+}
+
+// SetManyWithTTL is SetMany with entries which expire after ttl.
+func (c *Cache[K, V]) SetManyWithTTL(entries map[K]V, ttl time.Duration) {
+	if !c.begin() {
+		return
+	}
+	defer c.end()
+	keys := make([]K, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	for i, group := range c.groupByShard(keys) {
+		c.shards[i].setMany(group, entries, ttl)
+	}
+}
+
+// DeleteMany removes the keys, taking the lock of each shard once, and returns the number of keys removed.
+func (c *Cache[K, V]) DeleteMany(keys []K) int {
+	if !c.begin() {
+		return 0
+	}
+	defer c.end()
+	removed := 0
+	for i, group := range c.groupByShard(keys) {
+		removed += c.shards[i].removeMany(group)
+	}
+	return removed
+}
+
+// getMany reads the keys into values, appending the keys not found to missing and those due for a refresh to stale.
+func (s *cacheShard[K, V]) getMany(keys []K, values map[K]V, missing, stale []K) ([]K, []K) {
+	s.lock.Lock()
+	defer s.unlock()
+	for _, key := range keys {
+		value, ok := s.read(key)
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		values[key] = value
+		if s.stale(key) {
+			stale = append(stale, key)
+		}
+	}
+	return missing, stale
+}
+
+func (s *cacheShard[K, V]) setMany(keys []K, entries map[K]V, ttl time.Duration) {
+	s.lock.Lock()
+	defer s.unlock()
+	for _, key := range keys {
+		value := entries[key]
+		s.add(key, value, ttl, s.costOf(key, value))
+	}
+}
+
+func (s *cacheShard[K, V]) removeMany(keys []K) int {
+	s.lock.Lock()
+	defer s.unlock()
+	removed := 0
+	for _, key := range keys {
+		if s.cache.Remove(key) {
+			s.stats.delete()
+			removed++
+		}
+	}
+	return removed
+}
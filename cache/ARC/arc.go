@@ -0,0 +1,382 @@
+package ARC
+
+import (
+	"bitbucket.org/funplus/gcache/cache"
+	"container/list"
+	"time"
+)
+
+const Name = cache.EVICT_STRATEGY("ARC")
+
+type aRCCacheBuilder[K comparable, V any] struct{}
+
+func NewBuilder[K comparable, V any]() cache.CacheBuilder[K, V] {
+	return &aRCCacheBuilder[K, V]{}
+}
+
+func init() {
+	cache.Register(NewBuilder[interface{}, interface{}]())
+}
+
+func (*aRCCacheBuilder[K, V]) Build(maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V]) cache.ICache[K, V] {
+	return newARCCache[K, V](maxEntries, expiration, onEvict)
+}
+
+func (*aRCCacheBuilder[K, V]) Name() string {
+	return Name
+}
+
+type arcEntry[K comparable, V any] struct {
+	cache.Entry[K, V]
+	// frequent reports whether the entry lives in t2 (seen at least twice) rather than t1.
+	frequent bool
+}
+
+// ghost is a key evicted from t1 (into b1) or t2 (into b2), kept without its value.
+type ghost[K comparable] struct {
+	key      K
+	frequent bool
+}
+
+// ARCCache is an Adaptive Replacement Cache, it tracks both recency (t1) and frequency (t2) of entries,
+// plus the keys recently evicted from each (b1 and b2), and uses ghost hits to move the target size p of t1.
+// It is not safe for concurrent access.
+type ARCCache[K comparable, V any] struct {
+	// size is the maximum number of cache entries before
+	// an item is evicted. Zero means no limit.
+	size uint32
+	// p is the target size of t1.
+	p uint32
+	// OnEvicted optionally specifies a callback function to be
+	// executed when an entry is purged from the cache.
+	onEvicted cache.EvictCallback[K, V]
+	t1        *list.List
+	t2        *list.List
+	b1        *list.List
+	b2        *list.List
+	// items holds the resident entries of t1 and t2.
+	items map[K]*list.Element
+	// ghosts holds the keys of b1 and b2.
+	ghosts     map[K]*list.Element
+	expiration time.Duration
+	// wheel schedules the expiration of the resident entries.
+	wheel *cache.TimerWheel[K, V]
+}
+
+// New creates a new Cache.
+// If maxEntries is zero, the cache has no limit and it's assumed
+// that eviction is done by the caller.
+func newARCCache[K comparable, V any](maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V]) *ARCCache[K, V] {
+	c := &ARCCache[K, V]{
+		size:       maxEntries,
+		expiration: expiration,
+		onEvicted:  onEvict,
+	}
+	c.init()
+	return c
+}
+
+func (c *ARCCache[K, V]) init() {
+	c.p = 0
+	c.t1 = list.New()
+	c.t2 = list.New()
+	c.b1 = list.New()
+	c.b2 = list.New()
+	c.items = make(map[K]*list.Element, c.size)
+	c.ghosts = make(map[K]*list.Element, c.size)
+	c.wheel = cache.NewTimerWheel[K, V]()
+}
+
+// Keys returns a slice of the keys in the cache, the recent ones (t1) from oldest to newest
+// followed by the frequent ones (t2) from oldest to newest.
+func (c *ARCCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.items))
+	if c.items == nil {
+		return keys
+	}
+	for _, l := range []*list.List{c.t1, c.t2} {
+		for ent := l.Back(); ent != nil; ent = ent.Prev() {
+			keys = append(keys, ent.Value.(*arcEntry[K, V]).Key)
+		}
+	}
+	return keys
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *ARCCache[K, V]) Add(key K, value V) bool {
+	return c.AddWithTTL(key, value, c.expiration)
+}
+
+// AddWithTTL adds a value to the cache which expires after ttl, zero means never.
+func (c *ARCCache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) bool {
+	if c.items == nil {
+		c.init()
+	}
+	expireAt := cache.Deadline(ttl)
+	if ele, ok := c.items[key]; ok {
+		kv := ele.Value.(*arcEntry[K, V])
+		kv.Value = value
+		kv.Timestamp = expireAt
+		c.wheel.Schedule(&kv.Entry)
+		c.promote(ele)
+		return true
+	}
+
+	if c.size == 0 {
+		c.push(c.t1, &arcEntry[K, V]{Entry: cache.Entry[K, V]{Key: key, Value: value, Timestamp: expireAt}})
+		return true
+	}
+
+	if g, ok := c.ghosts[key]; ok {
+		// a ghost hit means the key was evicted too early, grow the list it was evicted from.
+		if g.Value.(*ghost[K]).frequent {
+			delta := uint32(1)
+			if c.b2.Len() < c.b1.Len() {
+				delta = uint32(c.b1.Len() / c.b2.Len())
+			}
+			if delta > c.p {
+				c.p = 0
+			} else {
+				c.p -= delta
+			}
+		} else {
+			delta := uint32(1)
+			if c.b1.Len() < c.b2.Len() {
+				delta = uint32(c.b2.Len() / c.b1.Len())
+			}
+			c.p += delta
+			if c.p > c.size {
+				c.p = c.size
+			}
+		}
+		frequent := g.Value.(*ghost[K]).frequent
+		c.removeGhost(g)
+		if uint32(len(c.items)) >= c.size {
+			c.replace(frequent)
+		}
+		c.push(c.t2, &arcEntry[K, V]{Entry: cache.Entry[K, V]{Key: key, Value: value, Timestamp: expireAt}, frequent: true})
+		return true
+	}
+
+	// a complete miss keeps |t1|+|b1| <= size and the four lists within 2*size.
+	if uint32(c.t1.Len()+c.b1.Len()) >= c.size {
+		if uint32(c.t1.Len()) < c.size {
+			c.removeGhost(c.b1.Back())
+			if uint32(len(c.items)) >= c.size {
+				c.replace(false)
+			}
+		} else {
+			// b1 is empty, the oldest recent entry is dropped without a ghost.
+			c.removeElement(c.t1.Back(), cache.NoSpace)
+		}
+	} else {
+		if uint32(len(c.items)+c.b1.Len()+c.b2.Len()) >= 2*c.size {
+			c.removeGhost(c.b2.Back())
+		}
+		if uint32(len(c.items)) >= c.size {
+			c.replace(false)
+		}
+	}
+	c.push(c.t1, &arcEntry[K, V]{Entry: cache.Entry[K, V]{Key: key, Value: value, Timestamp: expireAt}})
+	return true
+}
+
+func (c *ARCCache[K, V]) push(l *list.List, kv *arcEntry[K, V]) {
+	c.items[kv.Key] = l.PushFront(kv)
+	c.wheel.Schedule(&kv.Entry)
+}
+
+// promote moves a resident entry to the front of t2.
+func (c *ARCCache[K, V]) promote(ele *list.Element) {
+	kv := ele.Value.(*arcEntry[K, V])
+	if kv.frequent {
+		c.t2.MoveToFront(ele)
+		return
+	}
+	c.t1.Remove(ele)
+	kv.frequent = true
+	c.push(c.t2, kv)
+}
+
+// replace evicts an entry from t1 or t2 depending on the target p, remembering its key in b1 or b2.
+func (c *ARCCache[K, V]) replace(b2Hit bool) {
+	t1Len := uint32(c.t1.Len())
+	if t1Len > 0 && (t1Len > c.p || (t1Len == c.p && b2Hit) || c.t2.Len() == 0) {
+		c.evict(c.t1.Back(), c.b1)
+		return
+	}
+	if ele := c.t2.Back(); ele != nil {
+		c.evict(ele, c.b2)
+	}
+}
+
+func (c *ARCCache[K, V]) evict(ele *list.Element, ghosts *list.List) {
+	kv := ele.Value.(*arcEntry[K, V])
+	c.removeElement(ele, cache.NoSpace)
+	c.ghosts[kv.Key] = ghosts.PushFront(&ghost[K]{key: kv.Key, frequent: kv.frequent})
+	if uint32(ghosts.Len()) > c.size {
+		c.removeGhost(ghosts.Back())
+	}
+}
+
+func (c *ARCCache[K, V]) removeGhost(ele *list.Element) {
+	if ele == nil {
+		return
+	}
+	g := ele.Value.(*ghost[K])
+	if g.frequent {
+		c.b2.Remove(ele)
+	} else {
+		c.b1.Remove(ele)
+	}
+	delete(c.ghosts, g.key)
+}
+
+// Get looks up a key's value from the cache
+func (c *ARCCache[K, V]) Get(key K) (value V, ok bool) {
+	if c.items == nil {
+		return
+	}
+	if ele, hit := c.items[key]; hit {
+		kv := ele.Value.(*arcEntry[K, V])
+		now := time.Now().UnixNano()
+		if kv.Expired(now) {
+			c.removeElement(ele, cache.Expired)
+			return kv.Value, false
+		}
+		kv.Touch(now)
+		c.promote(ele)
+		return kv.Value, true
+	}
+	return
+}
+
+// GetEntry returns the entry of the key, expired or not, without updating
+// the "recently used"-ness of the key.
+func (c *ARCCache[K, V]) GetEntry(key K) (*cache.Entry[K, V], bool) {
+	if ele, hit := c.items[key]; hit {
+		return &ele.Value.(*arcEntry[K, V]).Entry, true
+	}
+	return nil, false
+}
+
+// Remove removes the provided key from the cache.
+func (c *ARCCache[K, V]) Remove(key K) bool {
+	if c.items == nil {
+		return false
+	}
+	if ele, hit := c.items[key]; hit {
+		c.removeElement(ele, cache.Deleted)
+		return true
+	}
+	return false
+}
+
+// RemoveOldest removes the entry ARC would replace next from the cache.
+func (c *ARCCache[K, V]) RemoveOldest() {
+	if c.items == nil || len(c.items) == 0 {
+		return
+	}
+	if c.size == 0 {
+		if ele := c.t1.Back(); ele != nil {
+			c.removeElement(ele, cache.NoSpace)
+		} else {
+			c.removeElement(c.t2.Back(), cache.NoSpace)
+		}
+		return
+	}
+	c.replace(false)
+}
+
+func (c *ARCCache[K, V]) removeElement(e *list.Element, reason cache.RemoveReason) {
+	kv := e.Value.(*arcEntry[K, V])
+	if kv.frequent {
+		c.t2.Remove(e)
+	} else {
+		c.t1.Remove(e)
+	}
+	c.wheel.Deschedule(&kv.Entry)
+	delete(c.items, kv.Key)
+	c.onEvicted(kv.Key, kv.Value, reason)
+}
+
+// Len returns the number of items in the cache.
+func (c *ARCCache[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+// An expired entry is removed.
+func (c *ARCCache[K, V]) Peek(key K) (value V, ok bool) {
+	var ele *list.Element
+	if ele, ok = c.items[key]; ok {
+		kv := ele.Value.(*arcEntry[K, V])
+		if kv.Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
+			return kv.Value, false
+		}
+		return kv.Value, true
+	}
+	return value, ok
+}
+
+// Contains checks if a key is in the cache, without updating the recent-ness,
+// removing it if expired.
+func (c *ARCCache[K, V]) Contains(key K) bool {
+	ele, ok := c.items[key]
+	if ok {
+		if ele.Value.(*arcEntry[K, V]).Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
+			return false
+		}
+	}
+	return ok
+}
+
+// CleanUp removes the entries expired at currentTimestamp, only the buckets of the timer wheel
+// which are due are visited.
+func (c *ARCCache[K, V]) CleanUp(currentTimestamp int64) {
+	if c.items == nil {
+		return
+	}
+	c.wheel.Advance(currentTimestamp, func(e *cache.Entry[K, V]) {
+		c.removeElement(c.items[e.Key], cache.Expired)
+	})
+}
+
+// Clear purges all stored items from the cache.
+func (c *ARCCache[K, V]) Clear() {
+	for _, e := range c.items {
+		kv := e.Value.(*arcEntry[K, V])
+		c.onEvicted(kv.Key, kv.Value, cache.Clear)
+	}
+	c.t1, c.t2, c.b1, c.b2 = nil, nil, nil, nil
+	c.items = nil
+	c.ghosts = nil
+	c.wheel = nil
+}
+
+// Resize changes the maximum number of entries, evicting entries over it as on a miss so that they are
+// remembered by the ghost lists, which are trimmed to the bounds of the new size. It returns the number of entries evicted.
+func (c *ARCCache[K, V]) Resize(size int) int {
+	c.size = uint32(max(size, 0))
+	if c.items == nil || c.size == 0 {
+		return 0
+	}
+	if c.p > c.size {
+		c.p = c.size
+	}
+	evicted := 0
+	for uint32(len(c.items)) > c.size {
+		c.replace(false)
+		evicted++
+	}
+	for c.b1.Len() > 0 && uint32(c.t1.Len()+c.b1.Len()) > c.size {
+		c.removeGhost(c.b1.Back())
+	}
+	for c.b2.Len() > 0 && uint32(len(c.items)+c.b1.Len()+c.b2.Len()) > 2*c.size {
+		c.removeGhost(c.b2.Back())
+	}
+	return evicted
+}
@@ -0,0 +1,274 @@
+package LFU
+
+import (
+	"bitbucket.org/funplus/gcache/cache"
+	"container/list"
+	"time"
+)
+
+const Name = cache.EVICT_STRATEGY("LFU")
+
+type lFUCacheBuilder[K comparable, V any] struct{}
+
+func NewBuilder[K comparable, V any]() cache.CacheBuilder[K, V] {
+	return &lFUCacheBuilder[K, V]{}
+}
+
+func init() {
+	cache.Register(NewBuilder[interface{}, interface{}]())
+}
+
+func (*lFUCacheBuilder[K, V]) Build(maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V]) cache.ICache[K, V] {
+	return newLFUCache[K, V](maxEntries, expiration, onEvict)
+}
+
+func (*lFUCacheBuilder[K, V]) Name() string {
+	return Name
+}
+
+// freqNode holds all entries sharing the same access frequency,
+// ordered from the most recently used (front) to the least (back).
+type freqNode struct {
+	freq  uint64
+	items *list.List
+}
+
+type lfuEntry[K comparable, V any] struct {
+	cache.Entry[K, V]
+	// parent is the element of freqList that holds this entry.
+	parent *list.Element
+}
+
+// LFUCache is an LFU cache with O(1) frequency buckets, ties within a bucket are broken by recency.
+// It is not safe for concurrent access.
+type LFUCache[K comparable, V any] struct {
+	// size is the maximum number of cache entries before
+	// an item is evicted. Zero means no limit.
+	size uint32
+	// OnEvicted optionally specifies a callback function to be
+	// executed when an entry is purged from the cache.
+	onEvicted cache.EvictCallback[K, V]
+	// freqList is ordered by frequency, lowest at the front.
+	freqList   *list.List
+	items      map[K]*list.Element
+	expiration time.Duration
+	// wheel schedules the expiration of the items.
+	wheel *cache.TimerWheel[K, V]
+}
+
+// New creates a new Cache.
+// If maxEntries is zero, the cache has no limit and it's assumed
+// that eviction is done by the caller.
+func newLFUCache[K comparable, V any](maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V]) *LFUCache[K, V] {
+	return &LFUCache[K, V]{
+		size:       maxEntries,
+		freqList:   list.New(),
+		items:      make(map[K]*list.Element, maxEntries),
+		expiration: expiration,
+		onEvicted:  onEvict,
+		wheel:      cache.NewTimerWheel[K, V](),
+	}
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be evicted
+// (least frequently used) to the last.
+func (c *LFUCache[K, V]) Keys() []K {
+	keys := make([]K, len(c.items))
+	if c.items == nil {
+		return keys
+	}
+	i := 0
+	for fe := c.freqList.Front(); fe != nil; fe = fe.Next() {
+		for ent := fe.Value.(*freqNode).items.Back(); ent != nil; ent = ent.Prev() {
+			keys[i] = ent.Value.(*lfuEntry[K, V]).Key
+			i++
+		}
+	}
+	return keys
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *LFUCache[K, V]) Add(key K, value V) bool {
+	return c.AddWithTTL(key, value, c.expiration)
+}
+
+// AddWithTTL adds a value to the cache which expires after ttl, zero means never.
+func (c *LFUCache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) bool {
+	if c.items == nil {
+		c.items = make(map[K]*list.Element)
+		c.freqList = list.New()
+		c.wheel = cache.NewTimerWheel[K, V]()
+	}
+	expireAt := cache.Deadline(ttl)
+	if ele, ok := c.items[key]; ok {
+		kv := ele.Value.(*lfuEntry[K, V])
+		kv.Value = value
+		kv.Timestamp = expireAt
+		c.wheel.Schedule(&kv.Entry)
+		c.increment(ele)
+		return true
+	}
+	if c.size != 0 && uint32(len(c.items)) >= c.size {
+		c.RemoveOldest()
+	}
+	front := c.freqList.Front()
+	if front == nil || front.Value.(*freqNode).freq != 1 {
+		front = c.freqList.PushFront(&freqNode{freq: 1, items: list.New()})
+	}
+	kv := &lfuEntry[K, V]{Entry: cache.Entry[K, V]{Key: key, Value: value, Timestamp: expireAt}, parent: front}
+	c.items[key] = front.Value.(*freqNode).items.PushFront(kv)
+	c.wheel.Schedule(&kv.Entry)
+	return true
+}
+
+// increment moves the entry to the bucket of the next frequency.
+func (c *LFUCache[K, V]) increment(ele *list.Element) {
+	kv := ele.Value.(*lfuEntry[K, V])
+	cur := kv.parent
+	node := cur.Value.(*freqNode)
+	next := cur.Next()
+	if next == nil || next.Value.(*freqNode).freq != node.freq+1 {
+		next = c.freqList.InsertAfter(&freqNode{freq: node.freq + 1, items: list.New()}, cur)
+	}
+	node.items.Remove(ele)
+	if node.items.Len() == 0 {
+		c.freqList.Remove(cur)
+	}
+	kv.parent = next
+	c.items[kv.Key] = next.Value.(*freqNode).items.PushFront(kv)
+}
+
+// Get looks up a key's value from the cache
+func (c *LFUCache[K, V]) Get(key K) (value V, ok bool) {
+	if c.items == nil {
+		return
+	}
+	if ele, hit := c.items[key]; hit {
+		kv := ele.Value.(*lfuEntry[K, V])
+		now := time.Now().UnixNano()
+		if kv.Expired(now) {
+			c.removeElement(ele, cache.Expired)
+			return kv.Value, false
+		}
+		kv.Touch(now)
+		c.increment(ele)
+		return kv.Value, true
+	}
+	return
+}
+
+// GetEntry returns the entry of the key, expired or not, without updating
+// the "recently used"-ness of the key.
+func (c *LFUCache[K, V]) GetEntry(key K) (*cache.Entry[K, V], bool) {
+	if ele, hit := c.items[key]; hit {
+		return &ele.Value.(*lfuEntry[K, V]).Entry, true
+	}
+	return nil, false
+}
+
+// Remove removes the provided key from the cache.
+func (c *LFUCache[K, V]) Remove(key K) bool {
+	if c.items == nil {
+		return false
+	}
+	if ele, hit := c.items[key]; hit {
+		c.removeElement(ele, cache.Deleted)
+		return true
+	}
+	return false
+}
+
+// RemoveOldest removes the least frequently used item from the cache,
+// the least recently used one among those with the same frequency.
+func (c *LFUCache[K, V]) RemoveOldest() {
+	if c.items == nil {
+		return
+	}
+	fe := c.freqList.Front()
+	if fe == nil {
+		return
+	}
+	if ele := fe.Value.(*freqNode).items.Back(); ele != nil {
+		c.removeElement(ele, cache.NoSpace)
+	}
+}
+
+func (c *LFUCache[K, V]) removeElement(e *list.Element, reason cache.RemoveReason) {
+	kv := e.Value.(*lfuEntry[K, V])
+	node := kv.parent.Value.(*freqNode)
+	node.items.Remove(e)
+	if node.items.Len() == 0 {
+		c.freqList.Remove(kv.parent)
+	}
+	c.wheel.Deschedule(&kv.Entry)
+	delete(c.items, kv.Key)
+	c.onEvicted(kv.Key, kv.Value, reason)
+}
+
+// Len returns the number of items in the cache.
+func (c *LFUCache[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the frequency of the key.
+// An expired entry is removed.
+func (c *LFUCache[K, V]) Peek(key K) (value V, ok bool) {
+	var ele *list.Element
+	if ele, ok = c.items[key]; ok {
+		kv := ele.Value.(*lfuEntry[K, V])
+		if kv.Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
+			return kv.Value, false
+		}
+		return kv.Value, true
+	}
+	return value, ok
+}
+
+// Contains checks if a key is in the cache, without updating the frequency,
+// removing it if expired.
+func (c *LFUCache[K, V]) Contains(key K) bool {
+	ele, ok := c.items[key]
+	if ok {
+		if ele.Value.(*lfuEntry[K, V]).Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
+			return false
+		}
+	}
+	return ok
+}
+
+// CleanUp removes the items expired at currentTimestamp, only the buckets of the timer wheel
+// which are due are visited.
+func (c *LFUCache[K, V]) CleanUp(currentTimestamp int64) {
+	if c.items == nil {
+		return
+	}
+	c.wheel.Advance(currentTimestamp, func(e *cache.Entry[K, V]) {
+		c.removeElement(c.items[e.Key], cache.Expired)
+	})
+}
+
+// Clear purges all stored items from the cache.
+func (c *LFUCache[K, V]) Clear() {
+	for _, e := range c.items {
+		kv := e.Value.(*lfuEntry[K, V])
+		c.onEvicted(kv.Key, kv.Value, cache.Clear)
+	}
+	c.freqList = nil
+	c.items = nil
+	c.wheel = nil
+}
+
+// Resize changes the maximum number of entries, evicting the oldest ones over it.
+// It returns the number of entries evicted.
+func (c *LFUCache[K, V]) Resize(size int) int {
+	c.size = uint32(max(size, 0))
+	evicted := 0
+	for c.size != 0 && uint32(c.Len()) > c.size {
+		c.RemoveOldest()
+		evicted++
+	}
+	return evicted
+}
@@ -8,78 +8,90 @@ import (
 
 const Name = cache.EVICT_STRATEGY("LRU")
 
-type lRUCacheBuilder struct{}
+type lRUCacheBuilder[K comparable, V any] struct{}
 
-func NewBuilder() cache.CacheBuilder {
-	return &lRUCacheBuilder{}
+func NewBuilder[K comparable, V any]() cache.CacheBuilder[K, V] {
+	return &lRUCacheBuilder[K, V]{}
 }
 
 func init() {
-	cache.Register(NewBuilder())
+	cache.Register(NewBuilder[interface{}, interface{}]())
 }
 
-func (*lRUCacheBuilder) Build(maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback) cache.ICache {
-	return newLRUCache(maxEntries, expiration, onEvict)
+func (*lRUCacheBuilder[K, V]) Build(maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V]) cache.ICache[K, V] {
+	return newLRUCache[K, V](maxEntries, expiration, onEvict)
 }
 
-func (*lRUCacheBuilder) Name() string {
+func (*lRUCacheBuilder[K, V]) Name() string {
 	return Name
 }
 
 // Cache is an LRUCache cache. It is not safe for concurrent access.
-type LRUCache struct {
+type LRUCache[K comparable, V any] struct {
 	// size is the maximum number of cache entries before
 	// an item is evicted. Zero means no limit.
 	size uint32
 	// OnEvicted optionally specifies a callback function to be
 	// executed when an entry is purged from the cache.
-	onEvicted  cache.EvictCallback
+	onEvicted  cache.EvictCallback[K, V]
 	evictList  *list.List
-	items      map[interface{}]*list.Element
+	items      map[K]*list.Element
 	expiration time.Duration
+	// wheel schedules the expiration of the items.
+	wheel *cache.TimerWheel[K, V]
 }
 
 // New creates a new Cache.
 // If maxEntries is zero, the cache has no limit and it's assumed
 // that eviction is done by the caller.
-func newLRUCache(maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback) *LRUCache {
-	return &LRUCache{
+func newLRUCache[K comparable, V any](maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V]) *LRUCache[K, V] {
+	return &LRUCache[K, V]{
 		size:       maxEntries,
 		evictList:  list.New(),
-		items:      make(map[interface{}]*list.Element, maxEntries),
+		items:      make(map[K]*list.Element, maxEntries),
 		expiration: expiration,
 		onEvicted:  onEvict,
+		wheel:      cache.NewTimerWheel[K, V](),
 	}
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
-func (c *LRUCache) Keys() []interface{} {
-	keys := make([]interface{}, len(c.items))
+func (c *LRUCache[K, V]) Keys() []K {
+	keys := make([]K, len(c.items))
 	i := 0
 	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
-		keys[i] = ent.Value.(*cache.Entry).Key
+		keys[i] = ent.Value.(*cache.Entry[K, V]).Key
 		i++
 	}
 	return keys
 }
 
 // Add adds a value to the cache, returns true if an eviction occurred.
-func (c *LRUCache) Add(key interface{}, value interface{}) bool {
+func (c *LRUCache[K, V]) Add(key K, value V) bool {
+	return c.AddWithTTL(key, value, c.expiration)
+}
+
+// AddWithTTL adds a value to the cache which expires after ttl, zero means never.
+func (c *LRUCache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) bool {
 	if c.items == nil {
-		c.items = make(map[interface{}]*list.Element)
+		c.items = make(map[K]*list.Element)
 		c.evictList = list.New()
+		c.wheel = cache.NewTimerWheel[K, V]()
 	}
-	expireAt := time.Now().Add(c.expiration).Unix()
+	expireAt := cache.Deadline(ttl)
 	if ee, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ee)
-		kv := ee.Value.(*cache.Entry)
+		kv := ee.Value.(*cache.Entry[K, V])
 		kv.Value = value
 		kv.Timestamp = expireAt
 		//atomic.StoreInt64(&kv.timestamp, expireAt)
+		c.wheel.Schedule(kv)
 		return true
 	}
-	ele := c.evictList.PushFront(&cache.Entry{key, value, expireAt})
+	kv := &cache.Entry[K, V]{Key: key, Value: value, Timestamp: expireAt}
+	ele := c.evictList.PushFront(kv)
 	c.items[key] = ele
+	c.wheel.Schedule(kv)
 	if c.size != 0 && uint32(c.evictList.Len()) > c.size {
 		c.RemoveOldest()
 	}
@@ -87,25 +99,36 @@ func (c *LRUCache) Add(key interface{}, value interface{}) bool {
 }
 
 // Get looks up a key's value from the cache
-func (c *LRUCache) Get(key interface{}) (value interface{}, ok bool) {
+func (c *LRUCache[K, V]) Get(key K) (value V, ok bool) {
 	if c.items == nil {
 		return
 	}
 	if ele, hit := c.items[key]; hit {
 		//expireAt := atomic.LoadInt64(&ele.Value.(*entry).timestamp)
-		kv := ele.Value.(*cache.Entry)
-		expireAt := ele.Value.(*cache.Entry).Timestamp
-		if c.expiration > 0 && time.Now().Unix() > expireAt {
+		kv := ele.Value.(*cache.Entry[K, V])
+		now := time.Now().UnixNano()
+		if kv.Expired(now) {
+			c.removeElement(ele, cache.Expired)
 			return kv.Value, false
 		}
+		kv.Touch(now)
 		c.evictList.MoveToFront(ele)
 		return kv.Value, true
 	}
 	return
 }
 
+// GetEntry returns the entry of the key, expired or not, without updating
+// the "recently used"-ness of the key.
+func (c *LRUCache[K, V]) GetEntry(key K) (*cache.Entry[K, V], bool) {
+	if ele, hit := c.items[key]; hit {
+		return ele.Value.(*cache.Entry[K, V]), true
+	}
+	return nil, false
+}
+
 // Remove removes the provided key from the cache.
-func (c *LRUCache) Remove(key interface{}) bool {
+func (c *LRUCache[K, V]) Remove(key K) bool {
 	if c.items == nil {
 		return false
 	}
@@ -117,7 +140,7 @@ func (c *LRUCache) Remove(key interface{}) bool {
 }
 
 // RemoveOldest removes the oldest item from the cache.
-func (c *LRUCache) RemoveOldest() {
+func (c *LRUCache[K, V]) RemoveOldest() {
 	if c.items == nil {
 		return
 	}
@@ -127,15 +150,16 @@ func (c *LRUCache) RemoveOldest() {
 	}
 }
 
-func (c *LRUCache) removeElement(e *list.Element, reason cache.RemoveReason) {
+func (c *LRUCache[K, V]) removeElement(e *list.Element, reason cache.RemoveReason) {
 	c.evictList.Remove(e)
-	kv := e.Value.(*cache.Entry)
+	kv := e.Value.(*cache.Entry[K, V])
+	c.wheel.Deschedule(kv)
 	delete(c.items, kv.Key)
 	c.onEvicted(kv.Key, kv.Value, reason)
 }
 
 // Len returns the number of items in the cache.
-func (c *LRUCache) Len() int {
+func (c *LRUCache[K, V]) Len() int {
 	if c.items == nil {
 		return 0
 	}
@@ -144,53 +168,65 @@ func (c *LRUCache) Len() int {
 
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
-func (c *LRUCache) Peek(key interface{}) (value interface{}, ok bool) {
+// An expired entry is removed.
+func (c *LRUCache[K, V]) Peek(key K) (value V, ok bool) {
 	var ele *list.Element
 	if ele, ok = c.items[key]; ok {
 		//expireAt := atomic.LoadInt64(&ent.Value.(*entry).timestamp)
-		kv := ele.Value.(*cache.Entry)
-		expireAt := ele.Value.(*cache.Entry).Timestamp
-		if c.expiration > 0 && time.Now().Unix() > expireAt {
+		kv := ele.Value.(*cache.Entry[K, V])
+		if kv.Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
 			return kv.Value, false
 		}
 		return kv.Value, true
 	}
-	return nil, ok
+	return value, ok
 }
 
-// Contains checks if a key is in the cache, without updating the recent-ness
-// or deleting it for being stale.
-func (c *LRUCache) Contains(key interface{}) bool {
+// Contains checks if a key is in the cache, without updating the recent-ness,
+// removing it if expired.
+func (c *LRUCache[K, V]) Contains(key K) bool {
 	ele, ok := c.items[key]
 	if ok {
 		//expireAt := atomic.LoadInt64(&ele.Value.(*entry).timestamp)
-		expireAt := ele.Value.(*cache.Entry).Timestamp
-		if c.expiration > 0 && time.Now().Unix() > expireAt {
+		if ele.Value.(*cache.Entry[K, V]).Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
 			return false
 		}
 	}
 	return ok
 }
 
-func (c *LRUCache) CleanUp(currentTimestamp int64) {
-	if c.expiration == 0 {
+// CleanUp removes the items expired at currentTimestamp, only the buckets of the timer wheel
+// which are due are visited.
+func (c *LRUCache[K, V]) CleanUp(currentTimestamp int64) {
+	if c.items == nil {
 		return
 	}
-	for _, e := range c.items {
-		//expireAt := atomic.LoadInt64(&e.Value.(*entry).timestamp)
-		expireAt := e.Value.(*cache.Entry).Timestamp
-		if currentTimestamp > expireAt {
-			c.removeElement(e, cache.Expired)
-		}
-	}
+	c.wheel.Advance(currentTimestamp, func(e *cache.Entry[K, V]) {
+		c.removeElement(c.items[e.Key], cache.Expired)
+	})
 }
 
 // Clear purges all stored items from the cache.
-func (c *LRUCache) Clear() {
+func (c *LRUCache[K, V]) Clear() {
 	for _, e := range c.items {
-		kv := e.Value.(*cache.Entry)
+		kv := e.Value.(*cache.Entry[K, V])
 		c.onEvicted(kv.Key, kv.Value, cache.Clear)
 	}
 	c.evictList = nil
 	c.items = nil
+	c.wheel = nil
+}
+
+// Resize changes the maximum number of entries, evicting the oldest ones over it.
+// It returns the number of entries evicted.
+func (c *LRUCache[K, V]) Resize(size int) int {
+	c.size = uint32(max(size, 0))
+	evicted := 0
+	for c.size != 0 && uint32(c.Len()) > c.size {
+		c.RemoveOldest()
+		evicted++
+	}
+	return evicted
 }
@@ -0,0 +1,170 @@
+package TinyLFU
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+const (
+	sketchDepth = 4
+	// maxCount is the saturation value of a counter.
+	maxCount = 15
+	// sampleFactor times the capacity is the number of recorded accesses after which all counters are halved.
+	sampleFactor = 10
+)
+
+// sketchSeeds derive the row indexes of a key from its hash.
+var sketchSeeds = [sketchDepth]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+// frequency is a count-min sketch estimating how often a key was accessed, guarded by a doorkeeper
+// bloom filter so that keys seen only once do not take room in the sketch.
+// Counters are periodically halved so that the history ages.
+type frequency struct {
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	door       *doorkeeper
+	additions  uint32
+	sampleSize uint32
+}
+
+func newFrequency(capacity uint32) *frequency {
+	if capacity == 0 {
+		capacity = 1
+	}
+	width := nextPowerOfTwo(uint64(capacity))
+	f := &frequency{
+		mask:       width - 1,
+		sampleSize: sampleFactor * capacity,
+		door:       newDoorkeeper(uint64(sampleFactor * capacity)),
+	}
+	for i := range f.rows {
+		f.rows[i] = make([]uint8, width)
+	}
+	return f
+}
+
+// increment records an access of the key hash.
+func (f *frequency) increment(h uint64) {
+	f.additions++
+	if f.additions >= f.sampleSize {
+		f.reset()
+	}
+	if !f.door.add(h) {
+		return
+	}
+	for i := range f.rows {
+		idx := f.index(h, i)
+		if f.rows[i][idx] < maxCount {
+			f.rows[i][idx]++
+		}
+	}
+}
+
+// estimate returns the estimated number of accesses of the key hash.
+func (f *frequency) estimate(h uint64) uint8 {
+	min := uint8(maxCount)
+	for i := range f.rows {
+		if c := f.rows[i][f.index(h, i)]; c < min {
+			min = c
+		}
+	}
+	if f.door.contains(h) {
+		min++
+	}
+	return min
+}
+
+// reset halves every counter and clears the doorkeeper.
+func (f *frequency) reset() {
+	for i := range f.rows {
+		for j := range f.rows[i] {
+			f.rows[i][j] >>= 1
+		}
+	}
+	f.door.reset()
+	f.additions /= 2
+}
+
+func (f *frequency) index(h uint64, i int) uint64 {
+	return mix(h^sketchSeeds[i]) & f.mask
+}
+
+// doorkeeper is a bloom filter remembering the keys accessed once since the last reset.
+type doorkeeper struct {
+	bits []uint64
+	mask uint64
+}
+
+func newDoorkeeper(insertions uint64) *doorkeeper {
+	// about 8 bits per insertion keeps the false positive rate low with two probes.
+	size := nextPowerOfTwo(insertions * 8)
+	if size < 64 {
+		size = 64
+	}
+	return &doorkeeper{bits: make([]uint64, size/64), mask: size - 1}
+}
+
+// add sets the bits of the key hash, returns true if they were all set already.
+func (d *doorkeeper) add(h uint64) bool {
+	present := true
+	for _, idx := range d.probes(h) {
+		word, bit := idx/64, uint64(1)<<(idx%64)
+		if d.bits[word]&bit == 0 {
+			present = false
+			d.bits[word] |= bit
+		}
+	}
+	return present
+}
+
+func (d *doorkeeper) contains(h uint64) bool {
+	for _, idx := range d.probes(h) {
+		if d.bits[idx/64]&(uint64(1)<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *doorkeeper) reset() {
+	for i := range d.bits {
+		d.bits[i] = 0
+	}
+}
+
+func (d *doorkeeper) probes(h uint64) [2]uint64 {
+	return [2]uint64{h & d.mask, mix(h) & d.mask}
+}
+
+func nextPowerOfTwo(v uint64) uint64 {
+	if v <= 1 {
+		return 1
+	}
+	return 1 << (64 - bits.LeadingZeros64(v-1))
+}
+
+// mix is the finalizer of splitmix64, it spreads the bits of v over the whole word.
+func mix(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
+
+// sketchSeed seeds the default hash of the keys, see sketchHasher.
+var sketchSeed = maphash.MakeSeed()
+
+// sketchHasher returns the hash of the keys in the sketch: hash mixed again, since the cache hasher gives the keys
+// of a shard the same low bits, or hash/maphash if hash is nil.
+func sketchHasher[K comparable](hash func(K) uint64) func(K) uint64 {
+	if hash == nil {
+		return func(key K) uint64 {
+			return maphash.Comparable(sketchSeed, key)
+		}
+	}
+	return func(key K) uint64 {
+		return mix(hash(key))
+	}
+}
@@ -0,0 +1,362 @@
+package TinyLFU
+
+import (
+	"bitbucket.org/funplus/gcache/cache"
+	"container/list"
+	"time"
+)
+
+const Name = cache.EVICT_STRATEGY("TinyLFU")
+
+const (
+	// windowPercent of the capacity is given to the admission window.
+	windowPercent = 1
+	// protectedPercent of the main space is given to the protected segment.
+	protectedPercent = 80
+)
+
+type tinyLFUCacheBuilder[K comparable, V any] struct {
+	// hash hashes the keys for the frequency sketch, hash/maphash if nil.
+	hash func(K) uint64
+}
+
+func NewBuilder[K comparable, V any]() cache.CacheBuilder[K, V] {
+	return &tinyLFUCacheBuilder[K, V]{}
+}
+
+// WithHasher returns a builder of caches hashing the keys with hash for the frequency sketch.
+func (*tinyLFUCacheBuilder[K, V]) WithHasher(hash func(K) uint64) cache.CacheBuilder[K, V] {
+	return &tinyLFUCacheBuilder[K, V]{hash: hash}
+}
+
+func init() {
+	cache.Register(NewBuilder[interface{}, interface{}]())
+}
+
+func (b *tinyLFUCacheBuilder[K, V]) Build(maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V]) cache.ICache[K, V] {
+	return newTinyLFUCache[K, V](maxEntries, expiration, onEvict, b.hash)
+}
+
+func (*tinyLFUCacheBuilder[K, V]) Name() string {
+	return Name
+}
+
+type segment uint8
+
+const (
+	window segment = iota
+	probation
+	protected
+)
+
+type tinyLFUEntry[K comparable, V any] struct {
+	cache.Entry[K, V]
+	hash    uint64
+	segment segment
+}
+
+// TinyLFUCache is a W-TinyLFU cache. New entries land in a small LRU admission window,
+// entries leaving the window must beat the eviction victim of the segmented LRU main space
+// (probation and protected) on the frequency estimated by a count-min sketch to be admitted.
+// It is not safe for concurrent access.
+type TinyLFUCache[K comparable, V any] struct {
+	// size is the maximum number of cache entries before
+	// an item is evicted. Zero means no limit.
+	size         uint32
+	windowSize   uint32
+	protectedCap uint32
+	// OnEvicted optionally specifies a callback function to be
+	// executed when an entry is purged from the cache.
+	onEvicted cache.EvictCallback[K, V]
+	window    *list.List
+	probation *list.List
+	protected *list.List
+	items     map[K]*list.Element
+	sketch    *frequency
+	// hash returns the hash of a key in the sketch.
+	hash       func(K) uint64
+	expiration time.Duration
+	// wheel schedules the expiration of the items.
+	wheel *cache.TimerWheel[K, V]
+}
+
+// New creates a new Cache.
+// If maxEntries is zero, the cache has no limit and it's assumed
+// that eviction is done by the caller.
+func newTinyLFUCache[K comparable, V any](maxEntries uint32, expiration time.Duration, onEvict cache.EvictCallback[K, V], hash func(K) uint64) *TinyLFUCache[K, V] {
+	c := &TinyLFUCache[K, V]{
+		expiration: expiration,
+		onEvicted:  onEvict,
+		hash:       sketchHasher(hash),
+	}
+	c.setSize(maxEntries)
+	c.init()
+	return c
+}
+
+// setSize sets the capacity of the cache and of its segments.
+func (c *TinyLFUCache[K, V]) setSize(maxEntries uint32) {
+	c.size = maxEntries
+	c.windowSize, c.protectedCap = 0, 0
+	if maxEntries > 0 {
+		c.windowSize = maxEntries * windowPercent / 100
+		if c.windowSize == 0 {
+			c.windowSize = 1
+		}
+		c.protectedCap = (maxEntries - c.windowSize) * protectedPercent / 100
+	}
+}
+
+func (c *TinyLFUCache[K, V]) init() {
+	c.window = list.New()
+	c.probation = list.New()
+	c.protected = list.New()
+	c.items = make(map[K]*list.Element, c.size)
+	c.sketch = newFrequency(c.size)
+	c.wheel = cache.NewTimerWheel[K, V]()
+}
+
+func (c *TinyLFUCache[K, V]) segmentList(s segment) *list.List {
+	switch s {
+	case window:
+		return c.window
+	case probation:
+		return c.probation
+	default:
+		return c.protected
+	}
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be evicted to the last:
+// probation, protected and then the admission window, each from oldest to newest.
+func (c *TinyLFUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.items))
+	if c.items == nil {
+		return keys
+	}
+	for _, l := range []*list.List{c.probation, c.protected, c.window} {
+		for ent := l.Back(); ent != nil; ent = ent.Prev() {
+			keys = append(keys, ent.Value.(*tinyLFUEntry[K, V]).Key)
+		}
+	}
+	return keys
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *TinyLFUCache[K, V]) Add(key K, value V) bool {
+	return c.AddWithTTL(key, value, c.expiration)
+}
+
+// AddWithTTL adds a value to the cache which expires after ttl, zero means never.
+func (c *TinyLFUCache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) bool {
+	if c.items == nil {
+		c.init()
+	}
+	expireAt := cache.Deadline(ttl)
+	if ele, ok := c.items[key]; ok {
+		kv := ele.Value.(*tinyLFUEntry[K, V])
+		kv.Value = value
+		kv.Timestamp = expireAt
+		c.wheel.Schedule(&kv.Entry)
+		c.sketch.increment(kv.hash)
+		c.onAccess(ele)
+		return true
+	}
+	kv := &tinyLFUEntry[K, V]{Entry: cache.Entry[K, V]{Key: key, Value: value, Timestamp: expireAt}, hash: c.hash(key), segment: window}
+	c.sketch.increment(kv.hash)
+	c.items[key] = c.window.PushFront(kv)
+	c.wheel.Schedule(&kv.Entry)
+	if c.size != 0 && uint32(c.window.Len()) > c.windowSize {
+		c.admit(c.window.Back())
+	}
+	return true
+}
+
+// admit moves the candidate evicted from the window into probation,
+// if the main space is full either the candidate or the main victim is evicted, whichever is less frequent.
+func (c *TinyLFUCache[K, V]) admit(candidate *list.Element) {
+	candidate = c.move(candidate, probation)
+	if uint32(len(c.items)) <= c.size {
+		return
+	}
+	victim := c.probation.Back()
+	if victim == candidate {
+		if ele := c.protected.Back(); ele != nil {
+			victim = ele
+		}
+	}
+	if victim != candidate &&
+		c.sketch.estimate(candidate.Value.(*tinyLFUEntry[K, V]).hash) <= c.sketch.estimate(victim.Value.(*tinyLFUEntry[K, V]).hash) {
+		victim = candidate
+	}
+	c.removeElement(victim, cache.NoSpace)
+}
+
+// move pushes the entry to the front of the target segment.
+func (c *TinyLFUCache[K, V]) move(ele *list.Element, target segment) *list.Element {
+	kv := ele.Value.(*tinyLFUEntry[K, V])
+	c.segmentList(kv.segment).Remove(ele)
+	kv.segment = target
+	ele = c.segmentList(target).PushFront(kv)
+	c.items[kv.Key] = ele
+	return ele
+}
+
+// onAccess updates the recency of the entry, entries hit in probation are promoted to protected.
+func (c *TinyLFUCache[K, V]) onAccess(ele *list.Element) {
+	kv := ele.Value.(*tinyLFUEntry[K, V])
+	switch kv.segment {
+	case window:
+		c.window.MoveToFront(ele)
+	case protected:
+		c.protected.MoveToFront(ele)
+	case probation:
+		c.move(ele, protected)
+		if uint32(c.protected.Len()) > c.protectedCap {
+			c.move(c.protected.Back(), probation)
+		}
+	}
+}
+
+// Get looks up a key's value from the cache
+func (c *TinyLFUCache[K, V]) Get(key K) (value V, ok bool) {
+	if c.items == nil {
+		return
+	}
+	if ele, hit := c.items[key]; hit {
+		kv := ele.Value.(*tinyLFUEntry[K, V])
+		now := time.Now().UnixNano()
+		if kv.Expired(now) {
+			c.removeElement(ele, cache.Expired)
+			return kv.Value, false
+		}
+		kv.Touch(now)
+		c.sketch.increment(kv.hash)
+		c.onAccess(ele)
+		return kv.Value, true
+	}
+	c.sketch.increment(c.hash(key))
+	return
+}
+
+// GetEntry returns the entry of the key, expired or not, without updating
+// the "recently used"-ness of the key.
+func (c *TinyLFUCache[K, V]) GetEntry(key K) (*cache.Entry[K, V], bool) {
+	if ele, hit := c.items[key]; hit {
+		return &ele.Value.(*tinyLFUEntry[K, V]).Entry, true
+	}
+	return nil, false
+}
+
+// Remove removes the provided key from the cache.
+func (c *TinyLFUCache[K, V]) Remove(key K) bool {
+	if c.items == nil {
+		return false
+	}
+	if ele, hit := c.items[key]; hit {
+		c.removeElement(ele, cache.Deleted)
+		return true
+	}
+	return false
+}
+
+// RemoveOldest removes the eviction victim from the cache, the oldest entry of probation,
+// then of protected and last of the admission window.
+func (c *TinyLFUCache[K, V]) RemoveOldest() {
+	if c.items == nil {
+		return
+	}
+	for _, l := range []*list.List{c.probation, c.protected, c.window} {
+		if ele := l.Back(); ele != nil {
+			c.removeElement(ele, cache.NoSpace)
+			return
+		}
+	}
+}
+
+func (c *TinyLFUCache[K, V]) removeElement(e *list.Element, reason cache.RemoveReason) {
+	kv := e.Value.(*tinyLFUEntry[K, V])
+	c.segmentList(kv.segment).Remove(e)
+	c.wheel.Deschedule(&kv.Entry)
+	delete(c.items, kv.Key)
+	c.onEvicted(kv.Key, kv.Value, reason)
+}
+
+// Len returns the number of items in the cache.
+func (c *TinyLFUCache[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness or the frequency of the key.
+// An expired entry is removed.
+func (c *TinyLFUCache[K, V]) Peek(key K) (value V, ok bool) {
+	var ele *list.Element
+	if ele, ok = c.items[key]; ok {
+		kv := ele.Value.(*tinyLFUEntry[K, V])
+		if kv.Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
+			return kv.Value, false
+		}
+		return kv.Value, true
+	}
+	return value, ok
+}
+
+// Contains checks if a key is in the cache, without updating the recent-ness,
+// removing it if expired.
+func (c *TinyLFUCache[K, V]) Contains(key K) bool {
+	ele, ok := c.items[key]
+	if ok {
+		if ele.Value.(*tinyLFUEntry[K, V]).Expired(time.Now().UnixNano()) {
+			c.removeElement(ele, cache.Expired)
+			return false
+		}
+	}
+	return ok
+}
+
+// CleanUp removes the items expired at currentTimestamp, only the buckets of the timer wheel
+// which are due are visited.
+func (c *TinyLFUCache[K, V]) CleanUp(currentTimestamp int64) {
+	if c.items == nil {
+		return
+	}
+	c.wheel.Advance(currentTimestamp, func(e *cache.Entry[K, V]) {
+		c.removeElement(c.items[e.Key], cache.Expired)
+	})
+}
+
+// Clear purges all stored items from the cache.
+func (c *TinyLFUCache[K, V]) Clear() {
+	for _, e := range c.items {
+		kv := e.Value.(*tinyLFUEntry[K, V])
+		c.onEvicted(kv.Key, kv.Value, cache.Clear)
+	}
+	c.window, c.probation, c.protected = nil, nil, nil
+	c.items = nil
+	c.sketch = nil
+	c.wheel = nil
+}
+
+// Resize changes the maximum number of entries, evicting the next ones to be evicted over it, then moves the
+// entries over the new segment capacities down to probation. The frequency sketch keeps its size and history.
+// It returns the number of entries evicted.
+func (c *TinyLFUCache[K, V]) Resize(size int) int {
+	c.setSize(uint32(max(size, 0)))
+	if c.items == nil || c.size == 0 {
+		return 0
+	}
+	evicted := 0
+	for uint32(len(c.items)) > c.size {
+		c.RemoveOldest()
+		evicted++
+	}
+	for uint32(c.protected.Len()) > c.protectedCap {
+		c.move(c.protected.Back(), probation)
+	}
+	for uint32(c.window.Len()) > c.windowSize {
+		c.move(c.window.Back(), probation)
+	}
+	return evicted
+}
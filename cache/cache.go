@@ -1,36 +1,46 @@
 package cache
 
+import "time"
+
 //warn:这是一个有些危险的操作，上层加锁删除元素，在evicte的回调中如果再次操作cache会陷入死锁
-type EvictCallback func(key interface{}, value interface{}, reason RemoveReason)
+// The onEvict given to CacheBuilder.Build runs under the shard lock, gcache delivers its OnRemoveCallbackFunc
+// only after the lock is released.
+type EvictCallback[K comparable, V any] func(key K, value V, reason RemoveReason)
 
 type EVICT_STRATEGY = string
 
-//evict_strategy_lfu EVICT_STRATEGY = "LFU" //TODO::未实现
-//evict_strategy_arc EVICT_STRATEGY = "ARC" //TODO::未实现
-
-type ICache interface {
+type ICache[K comparable, V any] interface {
 	// Adds a value to the cache, returns true if an eviction occurred and
 	// updates the "recently used"-ness of the key.
-	Add(key, value interface{}) bool
+	Add(key K, value V) bool
+
+	// Adds a value to the cache which expires after ttl instead of the cache expiration,
+	// a ttl of zero means the entry never expires.
+	AddWithTTL(key K, value V, ttl time.Duration) bool
 
 	// Returns key's value from the cache and
 	// updates the "recently used"-ness of the key. #value, isFound
-	Get(key interface{}) (value interface{}, ok bool)
+	// An expired entry is removed with the Expired reason, as by Peek and Contains.
+	Get(key K) (value V, ok bool)
+
+	// Returns key's entry, expired or not, without updating the "recently used"-ness of the key.
+	// The entry belongs to the cache and must not be kept once the caller releases its lock.
+	GetEntry(key K) (entry *Entry[K, V], ok bool)
 
 	// Checks if a key exists in cache without updating the recent-ness.
-	Contains(key interface{}) (ok bool)
+	Contains(key K) (ok bool)
 
 	// Returns key's value without updating the "recently used"-ness of the key.
-	Peek(key interface{}) (value interface{}, ok bool)
+	Peek(key K) (value V, ok bool)
 
 	// Removes a key from the cache.
-	Remove(key interface{}) bool
+	Remove(key K) bool
 
 	// Removes the oldest entry from cache.
 	RemoveOldest()
 
 	// Returns a slice of the keys in the cache, from oldest to newest.
-	Keys() []interface{}
+	Keys() []K
 
 	// Returns the number of items in the cache.
 	Len() int
@@ -38,9 +48,10 @@ type ICache interface {
 	// Clears all cache entries.
 	Clear()
 
-	// clean up items expired
+	// clean up items expired at currentTimestamp (unix nanoseconds)
 	CleanUp(currentTimestamp int64)
 
 	// Resizes cache, returning number evicted
-	// Resize(int) int
+	// The entries over the new size are evicted with the NoSpace reason, a size of zero means no limit.
+	Resize(int) int
 }
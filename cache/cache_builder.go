@@ -6,22 +6,42 @@ import (
 )
 
 var (
-	// m is a map from name to cache builder.
-	m = make(map[string]CacheBuilder)
+	// m is a map from name to the cache builders registered under it, one per key and value types.
+	m = make(map[string][]interface{})
 )
 
-func Register(b CacheBuilder) {
-	m[strings.ToLower(b.Name())] = b
+// Register registers the builder of a strategy for the key and value types K and V,
+// replacing the one registered before under the same name for the same types.
+func Register[K comparable, V any](b CacheBuilder[K, V]) {
+	name := strings.ToLower(b.Name())
+	for i, r := range m[name] {
+		if _, ok := r.(CacheBuilder[K, V]); ok {
+			m[name][i] = b
+			return
+		}
+	}
+	m[name] = append(m[name], b)
 }
 
-type CacheBuilder interface {
-	Build(maxEntries uint32, expiration time.Duration, onEvict EvictCallback) ICache
+type CacheBuilder[K comparable, V any] interface {
+	Build(maxEntries uint32, expiration time.Duration, onEvict EvictCallback[K, V]) ICache[K, V]
 	Name() string
 }
 
-func Get(name string) CacheBuilder {
-	if b, ok := m[strings.ToLower(name)]; ok {
-		return b
+// HashingBuilder is implemented by the builders of strategies which hash the keys, as TinyLFU does for its
+// frequency sketch. gcache builds them with its Hasher, which hashes the keys without boxing them.
+type HashingBuilder[K comparable, V any] interface {
+	CacheBuilder[K, V]
+	// WithHasher returns a builder of caches hashing the keys with hash.
+	WithHasher(hash func(K) uint64) CacheBuilder[K, V]
+}
+
+// Get returns the builder registered under name for the key and value types K and V.
+func Get[K comparable, V any](name string) CacheBuilder[K, V] {
+	for _, r := range m[strings.ToLower(name)] {
+		if b, ok := r.(CacheBuilder[K, V]); ok {
+			return b
+		}
 	}
 	return nil
 }
@@ -1,7 +1,47 @@
 package cache
 
-type Entry struct {
-	Key       interface{}
-	Value     interface{}
+import "time"
+
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+	// Timestamp is the expiration deadline in unix nanoseconds, zero means the entry never expires.
 	Timestamp int64
+	// Idle, when positive, makes the entry expire once not read for Idle: Touch pushes the Timestamp back
+	// on every read, never past WriteDeadline if set.
+	Idle          time.Duration
+	WriteDeadline int64
+	// RefreshAt is when the value is due for a reload in unix nanoseconds, zero means never.
+	// It is kept by the owner of the cache, the strategies leave it alone.
+	RefreshAt int64
+
+	// prev and next link the entry into its bucket of a TimerWheel.
+	prev, next *Entry[K, V]
+}
+
+// Expired reports whether the entry is past its deadline at currentTimestamp.
+func (e *Entry[K, V]) Expired(currentTimestamp int64) bool {
+	return e.Timestamp > 0 && currentTimestamp > e.Timestamp
+}
+
+// Touch extends the Timestamp of an entry read at currentTimestamp by its Idle time.
+// The entry is not rescheduled, the TimerWheel reschedules it when its former bucket is due.
+func (e *Entry[K, V]) Touch(currentTimestamp int64) {
+	if e.Idle <= 0 {
+		return
+	}
+	deadline := currentTimestamp + int64(e.Idle)
+	if e.WriteDeadline > 0 && deadline > e.WriteDeadline {
+		deadline = e.WriteDeadline
+	}
+	e.Timestamp = deadline
+}
+
+// Deadline returns the Timestamp of an entry written now with the given ttl,
+// zero (never expire) if ttl is not positive.
+func Deadline(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
 }
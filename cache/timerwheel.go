@@ -0,0 +1,121 @@
+package cache
+
+import "time"
+
+var (
+	// wheelBuckets is the number of buckets of each level of the wheel, powers of two.
+	wheelBuckets = [...]int64{64, 64, 32, 4, 1}
+	// wheelShifts is log2 of the time span of one bucket of each level:
+	// about 1.07s, 1.14m, 1.22h, 1.63d, and 6.5d for the overflow level.
+	wheelShifts = [...]uint{30, 36, 42, 47, 49}
+)
+
+// TimerWheel is a hierarchical timing wheel of entries keyed by their Timestamp, it lets a cache
+// expire its entries in O(expired) instead of scanning all of them.
+// Each level is a ring of buckets covering a growing time span, entries are linked into the bucket
+// of the level whose span fits their remaining ttl, and cascade down as the wheel advances.
+// It is not safe for concurrent access.
+type TimerWheel[K comparable, V any] struct {
+	// wheel holds the sentinel of every bucket of every level.
+	wheel [len(wheelBuckets)][]*Entry[K, V]
+	// nanos is the time of the last advance, in unix nanoseconds.
+	nanos int64
+}
+
+func NewTimerWheel[K comparable, V any]() *TimerWheel[K, V] {
+	w := &TimerWheel[K, V]{nanos: time.Now().UnixNano()}
+	for i := range w.wheel {
+		w.wheel[i] = make([]*Entry[K, V], wheelBuckets[i])
+		for j := range w.wheel[i] {
+			sentinel := &Entry[K, V]{}
+			sentinel.prev, sentinel.next = sentinel, sentinel
+			w.wheel[i][j] = sentinel
+		}
+	}
+	return w
+}
+
+// Schedule links the entry into the bucket of its Timestamp, moving it if it was already scheduled.
+// Entries which never expire are left out of the wheel.
+func (w *TimerWheel[K, V]) Schedule(e *Entry[K, V]) {
+	w.Deschedule(e)
+	if e.Timestamp == 0 {
+		return
+	}
+	sentinel := w.findBucket(e.Timestamp)
+	e.prev = sentinel.prev
+	e.next = sentinel
+	sentinel.prev.next = e
+	sentinel.prev = e
+}
+
+// Deschedule unlinks the entry from the wheel, if scheduled.
+func (w *TimerWheel[K, V]) Deschedule(e *Entry[K, V]) {
+	if e.next == nil {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next = nil, nil
+}
+
+// Advance moves the wheel to currentTimestamp (unix nanoseconds), calling expire for every entry
+// expired by then and cascading the others of the buckets passed over to lower levels.
+// expire is called after the entry was unlinked, it is expected to remove the entry from the cache.
+func (w *TimerWheel[K, V]) Advance(currentTimestamp int64, expire func(e *Entry[K, V])) {
+	previous := w.nanos
+	if currentTimestamp < previous {
+		currentTimestamp = previous
+	}
+	w.nanos = currentTimestamp
+	for i := range w.wheel {
+		previousTicks := previous >> wheelShifts[i]
+		delta := (currentTimestamp >> wheelShifts[i]) - previousTicks
+		// the current bucket of the lowest level is always visited so that nothing is left past its deadline.
+		if i > 0 && delta <= 0 {
+			break
+		}
+		w.expire(i, previousTicks, delta, expire)
+	}
+}
+
+// expire visits the buckets of the level from previousTicks to previousTicks+delta.
+func (w *TimerWheel[K, V]) expire(level int, previousTicks, delta int64, expire func(e *Entry[K, V])) {
+	buckets := w.wheel[level]
+	mask := wheelBuckets[level] - 1
+	steps := delta + 1
+	if steps > wheelBuckets[level] {
+		steps = wheelBuckets[level]
+	}
+	for i := int64(0); i < steps; i++ {
+		sentinel := buckets[(previousTicks+i)&mask]
+		e := sentinel.next
+		sentinel.prev, sentinel.next = sentinel, sentinel
+		for e != sentinel {
+			next := e.next
+			e.prev, e.next = nil, nil
+			if e.Expired(w.nanos) {
+				expire(e)
+			} else {
+				w.Schedule(e)
+			}
+			e = next
+		}
+	}
+}
+
+// findBucket returns the sentinel of the bucket for the deadline.
+func (w *TimerWheel[K, V]) findBucket(deadline int64) *Entry[K, V] {
+	if deadline < w.nanos {
+		deadline = w.nanos
+	}
+	duration := deadline - w.nanos
+	last := len(w.wheel) - 1
+	for i := 0; i < last; i++ {
+		if duration < int64(1)<<wheelShifts[i+1] {
+			ticks := deadline >> wheelShifts[i]
+			return w.wheel[i][ticks&(wheelBuckets[i]-1)]
+		}
+	}
+	return w.wheel[last][0]
+}
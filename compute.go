@@ -0,0 +1,85 @@
+package gcache
+
+import "time"
+
+// ComputeAction tells Compute what to do with the entry once computed.
+type ComputeAction int
+
+const (
+	// ComputeKeep leaves the entry as it was, present or not.
+	ComputeKeep ComputeAction = iota
+	// ComputeReplace stores the computed value, adding the entry if absent.
+	ComputeReplace
+	// ComputeDelete removes the entry if present.
+	ComputeDelete
+)
+
+// ComputeFunc computes the new value of an entry from its current value, present telling whether there is one.
+type ComputeFunc[V any] func(old V, present bool) (newValue V, action ComputeAction)
+
+// Compute updates the entry of the key atomically: fn is called with the current value under the shard lock
+// and its action is applied before the lock is released. It returns the value of the entry afterwards and
+// whether it is present. fn must not use the cache, it would deadlock.
+func (c *Cache[K, V]) Compute(key K, fn ComputeFunc[V]) (V, bool) {
+	return c.ComputeWithTTL(key, c.cc.Expiration, fn)
+}
+
+// ComputeWithTTL is Compute with a replaced entry which expires after ttl.
+func (c *Cache[K, V]) ComputeWithTTL(key K, ttl time.Duration, fn ComputeFunc[V]) (V, bool) {
+	if !c.hashable(key) || !c.begin() {
+		var zero V
+		return zero, false
+	}
+	defer c.end()
+	shard := c.getShard(key)
+	return shard.compute(key, ttl, fn)
+}
+
+// ComputeIfAbsent returns the value of the key, storing the one returned by fn if the key is absent.
+// fn is called under the shard lock and must not use the cache.
+func (c *Cache[K, V]) ComputeIfAbsent(key K, fn func(key K) V) V {
+	value, _ := c.Compute(key, func(old V, present bool) (V, ComputeAction) {
+		if present {
+			return old, ComputeKeep
+		}
+		return fn(key), ComputeReplace
+	})
+	return value
+}
+
+// ComputeIfPresent replaces the value of the key by the one returned by fn if the key is present,
+// reporting whether it was. fn is called under the shard lock and must not use the cache.
+func (c *Cache[K, V]) ComputeIfPresent(key K, fn func(key K, old V) V) (V, bool) {
+	return c.Compute(key, func(old V, present bool) (V, ComputeAction) {
+		if !present {
+			return old, ComputeKeep
+		}
+		return fn(key, old), ComputeReplace
+	})
+}
+
+func (s *cacheShard[K, V]) compute(key K, ttl time.Duration, fn ComputeFunc[V]) (value V, ok bool) {
+	s.lock.Lock()
+	defer s.unlock()
+	old, present := s.read(key)
+	if !present {
+		var zero V
+		old = zero
+	}
+	value, action := fn(old, present)
+	switch action {
+	case ComputeReplace:
+		if !s.add(key, value, ttl, s.costOf(key, value)) {
+			return old, present
+		}
+		return value, true
+	case ComputeDelete:
+		if present && s.cache.Remove(key) {
+			s.stats.delete()
+		}
+		var zero V
+		return zero, false
+	default:
+		return old, present
+	}
+}
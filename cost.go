@@ -0,0 +1,126 @@
+package gcache
+
+import "reflect"
+
+// CostFunc returns the weight of an entry counted against the MaxCost option, typically its size in bytes.
+type CostFunc[K comparable, V any] func(key K, value V) int64
+
+// costFuncOf adapts the CostFunc option to the key and value types of a cache,
+// the size estimated by EstimateSize is used when no CostFunc is set.
+func costFuncOf[K comparable, V any](f CostFunc[interface{}, interface{}]) CostFunc[K, V] {
+	if f == nil {
+		return func(key K, value V) int64 {
+			return EstimateSize(key) + EstimateSize(value)
+		}
+	}
+	if kf, ok := interface{}(f).(CostFunc[K, V]); ok {
+		return kf
+	}
+	return func(key K, value V) int64 {
+		return f(key, value)
+	}
+}
+
+// maxSizeDepth bounds how deep EstimateSize follows pointers, slices, maps and interfaces.
+const maxSizeDepth = 8
+
+// EstimateSize returns an estimate of the memory used by v in bytes: the size of its type plus what it
+// references through strings, slices, maps, pointers and interfaces. Memory shared by several references
+// is counted once, channels and functions are counted by the size of their reference only.
+func EstimateSize(v interface{}) int64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	seen := make(map[uintptr]struct{})
+	return int64(rv.Type().Size()) + referencedSize(rv, seen, 0)
+}
+
+// referencedSize returns the size of the memory referenced by v, excluding the size of v itself.
+func referencedSize(v reflect.Value, seen map[uintptr]struct{}, depth int) int64 {
+	if depth > maxSizeDepth {
+		return 0
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Ptr:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + referencedSize(elem, seen, depth+1)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + referencedSize(elem, seen, depth+1)
+	case reflect.Slice:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		if hasReferences(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				size += referencedSize(v.Index(i), seen, depth+1)
+			}
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		if hasReferences(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				size += referencedSize(v.Index(i), seen, depth+1)
+			}
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		// entries plus about one byte of bucket overhead per entry.
+		t := v.Type()
+		size := int64(v.Len()) * int64(t.Key().Size()+t.Elem().Size()+1)
+		if hasReferences(t.Key()) || hasReferences(t.Elem()) {
+			iter := v.MapRange()
+			for iter.Next() {
+				size += referencedSize(iter.Key(), seen, depth+1) + referencedSize(iter.Value(), seen, depth+1)
+			}
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), seen, depth+1)
+		}
+		return size
+	}
+	return 0
+}
+
+// visited reports whether the memory at p was counted already, and marks it.
+func visited(p uintptr, seen map[uintptr]struct{}) bool {
+	if _, ok := seen[p]; ok {
+		return true
+	}
+	seen[p] = struct{}{}
+	return false
+}
+
+// hasReferences reports whether values of t may reference memory counted by referencedSize.
+func hasReferences(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	case reflect.Array:
+		return hasReferences(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasReferences(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
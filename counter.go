@@ -0,0 +1,143 @@
+package gcache
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// NotNumericError is returned by the increments when the value stored under the key is not of a numeric kind
+// they can update: IncrBy and DecrBy update integers, IncrByFloat floats.
+type NotNumericError struct {
+	Key   interface{}
+	Value interface{}
+	// Want is the kind of number expected, "integer" or "float".
+	Want string
+}
+
+func (e *NotNumericError) Error() string {
+	return fmt.Sprintf("gcache: value %v (%T) of key %v is not a %s", e.Value, e.Value, e.Key, e.Want)
+}
+
+// IncrBy adds delta to the integer stored under the key and returns the result. An absent key is created
+// with the value delta and the cache Expiration, which increments then leave unchanged, so that a counter
+// expires a fixed time after its first increment. A value of any integer type is kept in its type, a cache
+// of interface{} values creates an int64. It fails with a NotNumericError if the value is not an integer,
+// and with an error if the result overflows its type.
+func (c *Cache[K, V]) IncrBy(key K, delta int64) (int64, error) {
+	if !c.begin() {
+		return 0, ErrClosed
+	}
+	defer c.end()
+	if !c.hashable(key) {
+		return 0, ErrUnhashableKey
+	}
+	var result int64
+	_, err := c.getShard(key).update(key, c.cc.Expiration, func(old V, present bool) (V, error) {
+		number := numberOf(old, present, int64(0))
+		switch {
+		case number.CanInt():
+			result = number.Int() + delta
+			if (delta > 0 && result < number.Int()) || (delta < 0 && result > number.Int()) || number.OverflowInt(result) {
+				return old, fmt.Errorf("gcache: increment of key %v by %d overflows %s", key, delta, number.Type())
+			}
+			number.SetInt(result)
+		case number.CanUint():
+			if (delta < 0 && number.Uint() < uint64(-delta)) || number.Uint() > math.MaxInt64 {
+				return old, fmt.Errorf("gcache: increment of key %v by %d overflows %s", key, delta, number.Type())
+			}
+			result = int64(number.Uint()) + delta
+			if result < 0 || number.OverflowUint(uint64(result)) {
+				return old, fmt.Errorf("gcache: increment of key %v by %d overflows %s", key, delta, number.Type())
+			}
+			number.SetUint(uint64(result))
+		default:
+			return old, &NotNumericError{Key: key, Value: number.Interface(), Want: "integer"}
+		}
+		return valueOf[V](key, number, "integer")
+	})
+	return result, err
+}
+
+// DecrBy subtracts delta from the integer stored under the key, see IncrBy.
+func (c *Cache[K, V]) DecrBy(key K, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, fmt.Errorf("gcache: decrement of key %v by %d overflows", key, delta)
+	}
+	return c.IncrBy(key, -delta)
+}
+
+// IncrByFloat adds delta to the float stored under the key and returns the result, see IncrBy.
+// A cache of interface{} values creates a float64, it fails with a NotNumericError if the value is not a float.
+func (c *Cache[K, V]) IncrByFloat(key K, delta float64) (float64, error) {
+	if !c.begin() {
+		return 0, ErrClosed
+	}
+	defer c.end()
+	if !c.hashable(key) {
+		return 0, ErrUnhashableKey
+	}
+	var result float64
+	_, err := c.getShard(key).update(key, c.cc.Expiration, func(old V, present bool) (V, error) {
+		number := numberOf(old, present, float64(0))
+		if !number.CanFloat() {
+			return old, &NotNumericError{Key: key, Value: number.Interface(), Want: "float"}
+		}
+		result = number.Float() + delta
+		number.SetFloat(result)
+		result = number.Float()
+		return valueOf[V](key, number, "float")
+	})
+	return result, err
+}
+
+// numberOf returns a settable copy of the value to increment, for an absent key the zero of V,
+// or zero when V is an interface type.
+func numberOf[V any](old V, present bool, zero interface{}) reflect.Value {
+	value := reflect.ValueOf(&old).Elem()
+	if value.Kind() == reflect.Interface {
+		if !present || value.IsNil() {
+			value = reflect.ValueOf(zero)
+		} else {
+			value = value.Elem()
+		}
+	}
+	number := reflect.New(value.Type()).Elem()
+	number.Set(value)
+	return number
+}
+
+// valueOf returns the incremented number as a V, which fails if V is an interface the number does not implement.
+func valueOf[V any](key interface{}, number reflect.Value, want string) (V, error) {
+	value, ok := number.Interface().(V)
+	if !ok {
+		return value, &NotNumericError{Key: key, Value: number.Interface(), Want: want}
+	}
+	return value, nil
+}
+
+// update sets the value of the key to the one returned by fn, unless it fails. A present entry keeps its
+// expiration, an absent one is added with ttl.
+func (s *cacheShard[K, V]) update(key K, ttl time.Duration, fn func(old V, present bool) (V, error)) (V, error) {
+	s.lock.Lock()
+	defer s.unlock()
+	old, present := s.read(key)
+	if !present {
+		var zero V
+		old = zero
+	}
+	value, err := fn(old, present)
+	if err != nil {
+		return old, err
+	}
+	if e, found := s.cache.GetEntry(key); present && found {
+		e.Value = value
+		s.stats.set()
+		return value, nil
+	}
+	if !s.add(key, value, ttl, s.costOf(key, value)) {
+		return old, ErrCapacity
+	}
+	return value, nil
+}
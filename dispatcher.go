@@ -0,0 +1,136 @@
+package gcache
+
+import (
+	"bitbucket.org/funplus/gcache/cache"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy tells what to do with a removal notification when the callback queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue.
+	// The callback must then not write to its own cache, it could wait on itself.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop discards the notification.
+	OverflowDrop
+	// OverflowInline runs the callback in the goroutine which removed the entry, out of the queue order.
+	OverflowInline
+)
+
+// removal is an entry removed from a shard, waiting to be delivered to the OnRemoveCallbackFunc.
+type removal[K comparable, V any] struct {
+	key    K
+	value  V
+	reason cache.RemoveReason
+}
+
+// dispatcher delivers the removal notifications of a cache in order, from a single goroutine
+// reading a bounded queue.
+type dispatcher[K comparable, V any] struct {
+	queue    chan removal[K, V]
+	policy   OverflowPolicy
+	callback cache.EvictCallback[interface{}, interface{}]
+	logger   Logger
+	dropped  uint64
+	// lock guards stopped against the notifications being queued, so that none is queued once run drains
+	// the queue for the last time.
+	lock    sync.RWMutex
+	stopped bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newDispatcher[K comparable, V any](size int, policy OverflowPolicy, callback cache.EvictCallback[interface{}, interface{}], logger Logger) *dispatcher[K, V] {
+	if size < 0 {
+		size = 0
+	}
+	d := &dispatcher[K, V]{
+		queue:    make(chan removal[K, V], size),
+		policy:   policy,
+		callback: callback,
+		logger:   logger,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *dispatcher[K, V]) run() {
+	defer close(d.done)
+	for {
+		select {
+		case r := <-d.queue:
+			d.deliver(r)
+		case <-d.stop:
+			for {
+				select {
+				case r := <-d.queue:
+					d.deliver(r)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *dispatcher[K, V]) deliver(r removal[K, V]) {
+	defer func() {
+		if x := recover(); x != nil {
+			logPanic(d.logger, x)
+		}
+	}()
+	d.callback(r.key, r.value, r.reason)
+}
+
+// dispatch queues the notifications according to the overflow policy,
+// they are delivered inline once the dispatcher is closed.
+func (d *dispatcher[K, V]) dispatch(removed []removal[K, V]) {
+	for _, r := range d.enqueue(removed) {
+		d.deliver(r)
+	}
+}
+
+// enqueue queues the notifications under the read lock, returning those to deliver inline once it is released,
+// so that a callback delivered inline may use the cache while close waits for the lock.
+func (d *dispatcher[K, V]) enqueue(removed []removal[K, V]) (inline []removal[K, V]) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	if d.stopped {
+		return removed
+	}
+	for _, r := range removed {
+		switch d.policy {
+		case OverflowDrop:
+			select {
+			case d.queue <- r:
+			default:
+				if n := atomic.AddUint64(&d.dropped, 1); n&(n-1) == 0 {
+					d.logger.Warnf("gcache: removal callback queue is full, %d notifications dropped", n)
+				}
+			}
+		case OverflowInline:
+			select {
+			case d.queue <- r:
+			default:
+				inline = append(inline, r)
+			}
+		default:
+			// run keeps reading the queue until stopped is set, which waits for the lock.
+			d.queue <- r
+		}
+	}
+	return inline
+}
+
+// close stops the dispatcher once the queued notifications are delivered.
+func (d *dispatcher[K, V]) close() {
+	d.lock.Lock()
+	d.stopped = true
+	d.lock.Unlock()
+	close(d.stop)
+	<-d.done
+}
@@ -0,0 +1,120 @@
+package gcache
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no entry exists for the key.
+	ErrNotFound = errors.New("gcache: entry not found")
+	// ErrExpired is returned when the entry of the key was found past its expiration, it is removed.
+	ErrExpired = errors.New("gcache: entry expired")
+	// ErrClosed is returned by the operations on a closed cache.
+	ErrClosed = errors.New("gcache: cache closed")
+	// ErrLoaderFailed wraps the errors and panics of the loaders, the loader error is wrapped too.
+	ErrLoaderFailed = errors.New("gcache: loader failed")
+	// ErrCapacity is returned when the entry could not be kept, as when its cost exceeds the budget of its
+	// shard or the strategy refused to admit it.
+	ErrCapacity = errors.New("gcache: no capacity for the entry")
+	// ErrUnhashableKey is returned for the keys which can not be stored in a map, as a []byte in an interface{}.
+	ErrUnhashableKey = errors.New("gcache: key is not hashable")
+	// ErrNoLoader is returned by GetOrLoad and GetOrLoadMany when neither the call nor the Loader option gives a loader.
+	ErrNoLoader = errors.New("gcache: no loader")
+)
+
+// begin registers an operation in flight for Close to wait for, unless the cache is closed.
+// The operation must call end once complete.
+func (c *Cache[K, V]) begin() bool {
+	c.ops.Add(1)
+	if c.isClosed.Load() {
+		c.end()
+		return false
+	}
+	return true
+}
+
+// end completes an operation registered by begin, waking Close up after the last one.
+// Close sets isClosed before it reads ops, so either begin sees the cache closed or Close sees the operation.
+func (c *Cache[K, V]) end() {
+	if c.ops.Add(-1) == 0 && c.isClosed.Load() {
+		c.idleOnce.Do(func() { close(c.idle) })
+	}
+}
+
+// GetE is Get returning why the value was not found: ErrNotFound, ErrExpired or ErrClosed.
+func (c *Cache[K, V]) GetE(key K) (V, error) {
+	if !c.begin() {
+		var zero V
+		return zero, ErrClosed
+	}
+	defer c.end()
+	if !c.hashable(key) {
+		var zero V
+		return zero, ErrUnhashableKey
+	}
+	shard := c.getShard(key)
+	value, stale, err := shard.getE(key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	if stale && c.loader != nil {
+		c.refresh(shard, key, c.loader)
+	}
+	return value, nil
+}
+
+// SetE is Set returning ErrClosed, or ErrCapacity if the entry is heavier than the budget of its shard
+// or was evicted as soon as added.
+func (c *Cache[K, V]) SetE(key K, entity V) error {
+	return c.SetWithTTLE(key, entity, c.cc.Expiration)
+}
+
+// SetWithTTLE is SetE with an entry which expires after ttl.
+func (c *Cache[K, V]) SetWithTTLE(key K, entity V, ttl time.Duration) error {
+	if !c.begin() {
+		return ErrClosed
+	}
+	defer c.end()
+	if !c.hashable(key) {
+		return ErrUnhashableKey
+	}
+	return c.getShard(key).setE(key, entity, ttl)
+}
+
+// DeleteE is Delete returning ErrNotFound if the key was absent, or ErrClosed.
+func (c *Cache[K, V]) DeleteE(key K) error {
+	if !c.begin() {
+		return ErrClosed
+	}
+	defer c.end()
+	if !c.hashable(key) {
+		return ErrUnhashableKey
+	}
+	if !c.getShard(key).remove(key) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// getE is get returning ErrNotFound or ErrExpired instead of false.
+func (s *cacheShard[K, V]) getE(key K) (value V, stale bool, err error) {
+	s.lock.Lock()
+	defer s.unlock()
+	value, err = s.readE(key)
+	stale = err == nil && s.stale(key)
+	return
+}
+
+func (s *cacheShard[K, V]) setE(key K, value V, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.unlock()
+	if !s.add(key, value, ttl, s.costOf(key, value)) {
+		return ErrCapacity
+	}
+	if _, present := s.cache.GetEntry(key); !present {
+		return ErrCapacity
+	}
+	return nil
+}
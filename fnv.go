@@ -1,11 +1,17 @@
 package gcache
 
-import "fmt"
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
 
-// newDefaultHasher returns a new 64-bit FNV-1a Hasher which makes no memory allocations.
+// NewFNVHasher returns a new 64-bit FNV-1a Hasher which makes no memory allocations, the default Hasher
+// of the former versions. Integer keys are used as their own hash.
 // Its Sum64 method will lay the value out in big-endian byte order.
 // See https://en.wikipedia.org/wiki/Fowler–Noll–Vo_hash_function
-func newDefaultHasher() Hasher {
+func NewFNVHasher() Hasher[interface{}] {
 	return fnv64a{}
 }
 
@@ -23,6 +29,8 @@ func (f fnv64a) Sum64(key interface{}) uint64 {
 	switch t := key.(type) {
 	case string:
 		return f.hash(t)
+	case Hashable:
+		return t.Hash64()
 	case fmt.Stringer:
 		return f.hash(t.String())
 	case uint64:
@@ -45,13 +53,45 @@ func (f fnv64a) Sum64(key interface{}) uint64 {
 		return uint64(t)
 	case uint32:
 		return uint64(t)
-	case float32, float64:
-		k := fmt.Sprintf("%v", key)
-		return f.hash(k)
-	default:
-		fmt.Printf("ToUint64: type %v can not convert to Uint64 \n", key)
+	case float32:
+		if t == 0 {
+			t = 0 // -0 == +0, they must hash the same.
+		}
+		return f.hash(fmt.Sprintf("%v", t))
+	case float64:
+		if t == 0 {
+			t = 0
+		}
+		return f.hash(fmt.Sprintf("%v", t))
+	case bool:
+		if t {
+			return 1
+		}
 		return 0
+	case complex64:
+		return f.hashComplex(complex128(t))
+	case complex128:
+		return f.hashComplex(t)
+	default:
+		if key == nil || !reflect.TypeOf(key).Comparable() {
+			// the Cache rejects such keys before hashing them.
+			return 0
+		}
+		// arrays, structs, pointers, channels and the named types of the kinds above.
+		return maphash.Comparable(hashSeed, key)
+	}
+}
+
+// hashComplex hashes the bits of the parts of key, -0 and +0 which are equal hash the same.
+func (f fnv64a) hashComplex(key complex128) uint64 {
+	r, i := real(key), imag(key)
+	if r == 0 {
+		r = 0
+	}
+	if i == 0 {
+		i = 0
 	}
+	return math.Float64bits(r)*prime64 ^ math.Float64bits(i)
 }
 
 func (f fnv64a) hash(key string) uint64 {
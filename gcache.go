@@ -2,8 +2,16 @@ package gcache
 
 import (
 	"bitbucket.org/funplus/gcache/cache"
+	"bitbucket.org/funplus/gcache/cache/ARC"
+	"bitbucket.org/funplus/gcache/cache/LFU"
 	"bitbucket.org/funplus/gcache/cache/LRU"
+	"bitbucket.org/funplus/gcache/cache/TinyLFU"
+	"context"
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,52 +26,126 @@ const (
 
 const default_evict_strategy = LRU.Name
 
-type GCache struct {
-	name      string
-	shards    []*cacheShard
-	cc        *Options
+// ExpirationMode tells what the expiration of an entry counts from, the modes may be combined.
+type ExpirationMode int
+
+const (
+	// ExpireAfterWrite expires an entry once its ttl has passed since it was written.
+	ExpireAfterWrite ExpirationMode = 1 << iota
+	// ExpireAfterAccess expires an entry once it has not been read for its ttl, every read keeps it alive.
+	// Combined with ExpireAfterWrite, the ttl still bounds the life of the entry and AccessExpiration is the idle time.
+	ExpireAfterAccess
+)
+
+// Cache is a sharded cache of values V by keys K, created by New.
+type Cache[K comparable, V any] struct {
+	name   string
+	shards []*cacheShard[K, V]
+	cc     *Options
+	// dispatcher delivers removed entries to OnRemoveCallbackFunc, nil without callback.
+	dispatcher *dispatcher[K, V]
+	hasher     Hasher[K]
+	// checkKeys is set when the keys must be checked by hashable, see mayHoldUnhashable.
+	checkKeys bool
+	logger    Logger
+	loader    LoaderFunc[K, V]
 	shardMask uint64
 	close     chan struct{}
+	// isClosed is set by Close, ops counts the operations and loads in flight it waits for, idle is closed
+	// once they are complete and done once the shutdown is.
+	closeLock sync.Mutex
+	isClosed  atomic.Bool
+	ops       atomic.Int64
+	idleOnce  sync.Once
+	idle      chan struct{}
+	done      chan struct{}
 }
 
-func (g *GCache) evictCallback(key interface{}, value interface{}, reason cache.RemoveReason) {
-	l.Debugf("cache %s: key %v is evicted, value %v, reason %v", g.name, key, value, reason)
-	if g.cc.OnRemoveCallbackFunc != nil {
-		go func() {
-			defer PrintPanicStack()
-			g.cc.OnRemoveCallbackFunc(key, value, reason)
-		}()
+// GCache is a Cache of any keys and values.
+type GCache = Cache[interface{}, interface{}]
+
+// newBuilder returns the builder of the strategy for the key and value types K and V, given hash when
+// it hashes the keys. The builtin strategies are available for any types, others must have been registered for K and V.
+func newBuilder[K comparable, V any](strategy cache.EVICT_STRATEGY, hash func(K) uint64) cache.CacheBuilder[K, V] {
+	b := cache.Get[K, V](strategy)
+	if b == nil {
+		switch strings.ToLower(strategy) {
+		case strings.ToLower(LRU.Name):
+			b = LRU.NewBuilder[K, V]()
+		case strings.ToLower(LFU.Name):
+			b = LFU.NewBuilder[K, V]()
+		case strings.ToLower(ARC.Name):
+			b = ARC.NewBuilder[K, V]()
+		case strings.ToLower(TinyLFU.Name):
+			b = TinyLFU.NewBuilder[K, V]()
+		default:
+			return nil
+		}
+	}
+	if hb, ok := b.(cache.HashingBuilder[K, V]); ok {
+		return hb.WithHasher(hash)
+	}
+	return b
+}
+
+// evictCallback is called by the strategies under the shard lock, the OnRemoveCallbackFunc is
+// delivered later by the dispatcher once the lock is released.
+func (g *Cache[K, V]) evictCallback(key K, value V, reason cache.RemoveReason) {
+	if g.cc.Development {
+		g.logger.Debugf("cache %s: key %v is evicted, value %v, reason %v", g.name, key, value, reason)
+	}
+}
+
+// printPanicStack is PrintPanicStack with the logger of the cache.
+func (c *Cache[K, V]) printPanicStack() {
+	if x := recover(); x != nil {
+		logPanic(c.logger, x)
 	}
 }
 
 func NewGCache(name string, opts ...Option) (*GCache, error) {
-	gcache := &GCache{name: name}
+	return New[interface{}, interface{}](name, opts...)
+}
+
+// New creates a cache of values V by keys K, the type-safe counterpart of NewGCache.
+func New[K comparable, V any](name string, opts ...Option) (*Cache[K, V], error) {
+	gcache := &Cache[K, V]{name: name}
 	gcache.cc = NewOptions(opts...)
-	gcache.shards = make([]*cacheShard, gcache.cc.Shards)
+	gcache.logger = newLevelLogger(gcache.cc.Logger, gcache.cc.LogLevel)
+	gcache.hasher = hasherOf[K](gcache.cc.Hasher)
+	gcache.checkKeys = mayHoldUnhashable(reflect.TypeOf((*K)(nil)).Elem())
+	gcache.loader = loaderOf[K, V](gcache.cc.Loader)
+	gcache.shards = make([]*cacheShard[K, V], gcache.cc.Shards)
 	gcache.shardMask = uint64(gcache.cc.Shards - 1)
 	gcache.close = make(chan struct{})
-	setLogger(gcache.cc.Logger)
+	gcache.idle = make(chan struct{})
 	if !isPowerOfTwo(int(gcache.cc.Shards)) {
 		return nil, fmt.Errorf("Shards number: %d must be power of two", gcache.cc.Shards)
 	}
 
+	if gcache.cc.OnRemoveCallbackFunc != nil {
+		gcache.dispatcher = newDispatcher[K, V](gcache.cc.CallbackQueueSize, gcache.cc.CallbackOverflowPolicy, gcache.cc.OnRemoveCallbackFunc, gcache.logger)
+	}
 	for i := 0; i < int(gcache.cc.Shards); i++ {
 		shard, err := initNewShard(gcache)
 		if err != nil {
+			if gcache.dispatcher != nil {
+				gcache.dispatcher.close()
+			}
 			return nil, err
 		}
 		gcache.shards[i] = shard
 	}
 
-	if gcache.cc.CleanInterval > 0 && gcache.cc.Expiration > 0 {
+	if gcache.cc.CleanInterval > 0 {
 		go func() {
-			defer PrintPanicStack()
+			defer gcache.printPanicStack()
 			ticker := time.NewTicker(gcache.cc.CleanInterval)
 			defer ticker.Stop()
 			for {
 				select {
 				case t := <-ticker.C:
-					gcache.cleanUp(t.Unix())
+					gcache.cleanUp(t.UnixNano())
 				case <-gcache.close:
 					return
 				}
@@ -71,28 +153,113 @@ func NewGCache(name string, opts ...Option) (*GCache, error) {
 		}()
 	}
 
+	registerCache(name, gcache)
 	return gcache, nil
 }
 
-func (c *GCache) getShard(key interface{}) (shard *cacheShard) {
-	hashedKey := c.cc.Hasher.Sum64(key)
-	return c.shards[hashedKey&c.shardMask]
+func (c *Cache[K, V]) getShard(key K) (shard *cacheShard[K, V]) {
+	return c.shards[c.shardIndex(key)]
+}
+
+// shardIndex returns the index of the shard of the key.
+func (c *Cache[K, V]) shardIndex(key K) uint64 {
+	return c.hasher.Sum64(key) & c.shardMask
+}
+
+func (c *Cache[K, V]) Set(key K, entity V) bool {
+	return c.SetWithTTL(key, entity, c.cc.Expiration)
+}
+
+// SetWithTTL adds the entry which expires after ttl instead of the cache Expiration,
+// use NoExpiration for an entry which never expires.
+func (c *Cache[K, V]) SetWithTTL(key K, entity V, ttl time.Duration) bool {
+	if !c.hashable(key) || !c.begin() {
+		return false
+	}
+	defer c.end()
+	shard := c.getShard(key)
+	return shard.set(key, entity, ttl)
 }
 
-func (c *GCache) Set(key interface{}, entity interface{}) bool {
+// SetWithCost adds the entry with the given weight instead of the one computed by the CostFunc option,
+// entries are evicted until the shard of the key is within its share of MaxCost. It returns false,
+// leaving the shard unchanged, if cost exceeds that share.
+func (c *Cache[K, V]) SetWithCost(key K, entity V, cost int64) bool {
+	if !c.hashable(key) || !c.begin() {
+		return false
+	}
+	defer c.end()
 	shard := c.getShard(key)
-	return shard.set(key, entity)
+	return shard.setWithCost(key, entity, c.cc.Expiration, cost)
 }
 
 // Get reads entry for the key.
-// It returns an ErrEntryNotFound when
-// no entry exists for the given key.
-func (c *GCache) Get(key interface{}) (interface{}, bool) {
+// It returns false when no entry exists for the given key or it expired, see GetE to tell them apart.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	if !c.hashable(key) || !c.begin() {
+		var zero V
+		return zero, false
+	}
+	defer c.end()
 	shard := c.getShard(key)
-	return shard.get(key)
+	value, ok, stale := shard.get(key)
+	if stale && c.loader != nil {
+		c.refresh(shard, key, c.loader)
+	}
+	return value, ok
 }
 
-func (c *GCache) Count() int {
+// GetOrLoad reads entry for the key, calling loader on a miss and storing the loaded value.
+// Concurrent misses on the same key share one in-flight load per shard, the loader error is
+// returned to all of them and nothing is stored. When loader is nil the Loader option is used.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader LoaderFunc[K, V]) (V, error) {
+	if loader == nil {
+		loader = c.loader
+	}
+	if loader == nil {
+		var zero V
+		return zero, ErrNoLoader
+	}
+	if !c.hashable(key) {
+		var zero V
+		return zero, ErrUnhashableKey
+	}
+	if !c.begin() {
+		var zero V
+		return zero, ErrClosed
+	}
+	defer c.end()
+	shard := c.getShard(key)
+	if value, ok, stale := shard.get(key); ok {
+		if stale {
+			c.refresh(shard, key, loader)
+		}
+		return value, nil
+	}
+	return c.load(ctx, shard, key, loader, false)
+}
+
+// refresh reloads the key in the background, sharing the in-flight load of the key if any.
+// The current value is kept when the loader fails, a reloaded entry keeps its expiration.
+func (c *Cache[K, V]) refresh(shard *cacheShard[K, V], key K, loader LoaderFunc[K, V]) {
+	if !c.begin() {
+		return
+	}
+	go func() {
+		defer c.end()
+		defer c.printPanicStack()
+		if _, err := c.load(context.Background(), shard, key, loader, true); err != nil {
+			c.logger.Warnf("cache %s: refresh key %v: %v", c.name, key, err)
+		}
+	}()
+}
+
+// Count returns the number of live entries, 0 once closed.
+func (c *Cache[K, V]) Count() int {
+	if !c.begin() {
+		return 0
+	}
+	defer c.end()
 	count := 0
 	for _, shard := range c.shards {
 		count += shard.count()
@@ -100,30 +267,94 @@ func (c *GCache) Count() int {
 	return count
 }
 
-func (c *GCache) LoadOrStore(key interface{}, entity interface{}) (interface{}, bool) {
+func (c *Cache[K, V]) LoadOrStore(key K, entity V) (V, bool) {
+	return c.LoadOrStoreWithTTL(key, entity, c.cc.Expiration)
+}
+
+// LoadOrStoreWithTTL is LoadOrStore with an entry which expires after ttl.
+func (c *Cache[K, V]) LoadOrStoreWithTTL(key K, entity V, ttl time.Duration) (V, bool) {
+	if !c.hashable(key) || !c.begin() {
+		var zero V
+		return zero, false
+	}
+	defer c.end()
 	shard := c.getShard(key)
-	return shard.loadOrStore(key, entity)
+	return shard.loadOrStore(key, entity, ttl)
 }
 
-func (c *GCache) CompareAndSet(key interface{}, expect, update interface{}, equal func(old, new interface{}) bool) (interface{}, bool) {
+func (c *Cache[K, V]) CompareAndSet(key K, expect, update V, equal func(old, new V) bool) (V, bool) {
+	return c.CompareAndSetWithTTL(key, expect, update, c.cc.Expiration, equal)
+}
+
+// CompareAndSetWithTTL is CompareAndSet with an updated entry which expires after ttl.
+func (c *Cache[K, V]) CompareAndSetWithTTL(key K, expect, update V, ttl time.Duration, equal func(old, new V) bool) (V, bool) {
+	if !c.hashable(key) || !c.begin() {
+		var zero V
+		return zero, false
+	}
+	defer c.end()
 	shard := c.getShard(key)
-	return shard.compareAndSet(key, expect, update, equal)
+	return shard.compareAndSet(key, expect, update, ttl, equal)
 }
 
 // Delete removes the key
-func (c *GCache) Delete(key interface{}) bool {
+func (c *Cache[K, V]) Delete(key K) bool {
+	if !c.hashable(key) || !c.begin() {
+		return false
+	}
+	defer c.end()
 	shard := c.getShard(key)
 	return shard.remove(key)
 }
 
-//Contains contains the key
-func (c *GCache) Contains(key interface{}) bool {
+// Contains contains the key
+func (c *Cache[K, V]) Contains(key K) bool {
+	if !c.hashable(key) || !c.begin() {
+		return false
+	}
+	defer c.end()
 	shard := c.getShard(key)
 	return shard.contains(key)
 }
 
-// clean up keys expired
-func (c *GCache) cleanUp(currentTimestamp int64) {
+// Purge removes all the entries, they are delivered to the OnRemoveCallbackFunc with the Clear reason.
+func (c *Cache[K, V]) Purge() {
+	if !c.begin() {
+		return
+	}
+	defer c.end()
+	for _, shard := range c.shards {
+		shard.purge()
+	}
+}
+
+// Resize changes the capacity of the cache to maxEntries, divided between the shards without the minimum
+// MaxEntrySize gives them, the first maxEntries % Shards shards taking one more entry. The entries over the
+// capacity of their shard are evicted with the NoSpace reason, a shard given no capacity holds no entry.
+// It returns the number of entries evicted.
+func (c *Cache[K, V]) Resize(maxEntries uint32) int {
+	if !c.begin() {
+		return 0
+	}
+	defer c.end()
+	evicted := 0
+	shards := uint32(len(c.shards))
+	for i, shard := range c.shards {
+		size := maxEntries / shards
+		if uint32(i) < maxEntries%shards {
+			size++
+		}
+		evicted += shard.resize(size)
+	}
+	return evicted
+}
+
+// clean up keys expired, an operation Close waits for so that no removal is delivered once it returned
+func (c *Cache[K, V]) cleanUp(currentTimestamp int64) {
+	if !c.begin() {
+		return
+	}
+	defer c.end()
 	for _, shard := range c.shards {
 		shard.cleanUp(currentTimestamp)
 	}
@@ -132,7 +363,45 @@ func (c *GCache) cleanUp(currentTimestamp int64) {
 // Close is used to signal a shutdown of the cache when you are done with it.
 // This allows the cleaning goroutines to exit and ensures references are not
 // kept to the cache preventing GC of the entire cache.
-func (c *GCache) Close() error {
+//
+// Once closed, the operations fail with ErrClosed or report the key absent. Close waits for the operations
+// and loads in flight, removes the remaining entries with the Clear reason if ClearOnClose is set, and waits
+// for the queued removal callbacks to be delivered. If ctx is done first it returns ctx.Err() and the shutdown
+// completes in the background. Close may be called several times, only the first call shuts the cache down
+// and every call waits for the shutdown with its own ctx. It must not be called from within an operation of
+// the cache, as the fn of Range, which it would wait for.
+func (c *Cache[K, V]) Close(ctx context.Context) error {
+	c.closeLock.Lock()
+	if c.done == nil {
+		c.done = make(chan struct{})
+		c.isClosed.Store(true)
+		go c.shutdown()
+	}
+	done := c.done
+	c.closeLock.Unlock()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown completes the first Close, closing c.done once done.
+func (c *Cache[K, V]) shutdown() {
+	defer close(c.done)
+	unregisterCache(c.name, c)
 	close(c.close)
-	return nil
+	if c.ops.Load() == 0 {
+		c.idleOnce.Do(func() { close(c.idle) })
+	}
+	<-c.idle
+	if c.cc.ClearOnClose {
+		for _, shard := range c.shards {
+			shard.purge()
+		}
+	}
+	if c.dispatcher != nil {
+		c.dispatcher.close()
+	}
 }
@@ -12,25 +12,61 @@ func OptionsOptionDeclareWithDefault() interface{} {
 		"Shards": int32(1024),
 		// Time after which entry can be evicted
 		"Expiration": time.Duration(DefaultExpiration),
+		// What the Expiration of an entry counts from: its write, its last read or both, see ExpirationMode.
+		"ExpirationMode": ExpirationMode(ExpireAfterWrite),
+		// Time after which an entry not read is expired when ExpirationMode is ExpireAfterWrite|ExpireAfterAccess.
+		// Zero means the entries are only expired after write.
+		"AccessExpiration": time.Duration(0),
 		// Type of evict for cache, also its build type.
 		"EvictStrategy": cache.EVICT_STRATEGY(default_evict_strategy),
 		// Interval between removing expired entries (clean up).
-		// If set to <= 0 then no action is performed. Setting to < 1 second is counterproductive — gcache has a one second resolution.
+		// If set to <= 0 then no action is performed. Setting to < 1 second is counterproductive.
 		"CleanInterval": time.Duration(30 * time.Second),
 		// Max number of entries in life window. Used only to calculate initial size for cache Shards.
 		// When proper value is set then additional memory allocation does not occur.
 		"MaxEntrySize": uint32(1024 * 1024),
-		// Hasher used to map between string keys and unsigned 64bit integers, by default fnv64 hashing is used.
-		"Hasher": (Hasher)(newDefaultHasher()),
+		// Max total weight of the entries, as given by CostFunc or SetWithCost, divided evenly between the Shards.
+		// Entries are evicted through the EvictStrategy until the weight of a shard is within its budget.
+		// An entry heavier than the budget of its shard, MaxCost / Shards, is rejected and the other entries kept:
+		// with the default 1024 Shards, values of 2MB are only stored with a MaxCost of 2GB or more, use fewer
+		// Shards to store large values within a smaller MaxCost.
+		// Zero means no limit and no weight tracking.
+		"MaxCost": int64(0),
+		// CostFunc returns the weight of an entry, by default its size in bytes estimated by EstimateSize.
+		"CostFunc": (CostFunc[interface{}, interface{}])(nil),
+		// Hasher used to map between keys and unsigned 64bit integers, by default hash/maphash with integers mixed, see NewDefaultHasher.
+		"Hasher": (Hasher[interface{}])(NewDefaultHasher()),
 		// OnRemove is a callback fired when the oldest entry is removed because of its expiration time or no space left
 		// for the new entry, or because delete was called.
 		// Default value is nil which means no callback and it prevents from unwrapping the oldest entry.
+		// It is called in order from a single goroutine once the shard lock is released, so it may use the cache.
 		// ignored if OnRemoveWithMetadata is specified.
-		"OnRemoveCallbackFunc": (cache.EvictCallback)(nil),
-		// Development output evicted logs in development mode
+		"OnRemoveCallbackFunc": (cache.EvictCallback[interface{}, interface{}])(nil),
+		// Size of the queue of removal notifications waiting for OnRemoveCallbackFunc.
+		"CallbackQueueSize": int(1024),
+		// What to do with a removal notification when the callback queue is full: block, drop it or run it inline.
+		"CallbackOverflowPolicy": OverflowPolicy(OverflowInline),
+		// ClearOnClose removes the remaining entries on Close, they are delivered to OnRemoveCallbackFunc with the Clear reason.
+		"ClearOnClose": bool(false),
+		// Development output evicted logs in development mode, at the debug level
 		"Development": bool(true),
+		// Loader loads the value of a missing key for GetOrLoad when no loader is given to the call.
+		"Loader": (LoaderFunc[interface{}, interface{}])(nil),
+		// Deadline of a load, which is shared by the callers missing the key and so runs detached from their
+		// contexts: each caller only stops waiting for it when its own context is done.
+		// Zero means no deadline.
+		"LoadTimeout": time.Duration(0),
+		// Time after write from which a read through Get or GetOrLoad reloads the entry in the background
+		// with the loader, the current value is returned meanwhile and kept if the reload fails.
+		// Zero means the entries are never refreshed.
+		"RefreshAfterWrite": time.Duration(0),
+		// Codec encodes keys and values of the snapshots written by SaveTo, by default gob is used.
+		"Codec": (Codec)(newGobCodec()),
 		// Logger is a logging interface and used in combination with `Verbose`
-		// Defaults to `DefaultLogger()`
+		// Defaults to `DefaultLogger()`, see NewSlogLogger to log to a log/slog Logger.
+		// Each cache logs to its own Logger.
 		"Logger": (Logger)(nil),
+		// Lowest level of the messages logged to the Logger.
+		"LogLevel": LogLevel(LogDebug),
 	}
 }
@@ -9,15 +9,27 @@ import (
 )
 
 type Options struct {
-	Shards               int32
-	Expiration           time.Duration
-	EvictStrategy        cache.EVICT_STRATEGY
-	CleanInterval        time.Duration
-	MaxEntrySize         uint32
-	Hasher               Hasher
-	OnRemoveCallbackFunc cache.EvictCallback
-	Development          bool
-	Logger               Logger
+	Shards                 int32
+	Expiration             time.Duration
+	ExpirationMode         ExpirationMode
+	AccessExpiration       time.Duration
+	EvictStrategy          cache.EVICT_STRATEGY
+	CleanInterval          time.Duration
+	MaxEntrySize           uint32
+	MaxCost                int64
+	CostFunc               CostFunc[interface{}, interface{}]
+	Hasher                 Hasher[interface{}]
+	OnRemoveCallbackFunc   cache.EvictCallback[interface{}, interface{}]
+	CallbackQueueSize      int
+	CallbackOverflowPolicy OverflowPolicy
+	ClearOnClose           bool
+	Development            bool
+	Loader                 LoaderFunc[interface{}, interface{}]
+	LoadTimeout            time.Duration
+	RefreshAfterWrite      time.Duration
+	Codec                  Codec
+	Logger                 Logger
+	LogLevel               LogLevel
 }
 
 func (cc *Options) SetOption(opt Option) {
@@ -50,6 +62,20 @@ func WithExpiration(v time.Duration) Option {
 		return WithExpiration(previous)
 	}
 }
+func WithExpirationMode(v ExpirationMode) Option {
+	return func(cc *Options) Option {
+		previous := cc.ExpirationMode
+		cc.ExpirationMode = v
+		return WithExpirationMode(previous)
+	}
+}
+func WithAccessExpiration(v time.Duration) Option {
+	return func(cc *Options) Option {
+		previous := cc.AccessExpiration
+		cc.AccessExpiration = v
+		return WithAccessExpiration(previous)
+	}
+}
 func WithEvictStrategy(v cache.EVICT_STRATEGY) Option {
 	return func(cc *Options) Option {
 		previous := cc.EvictStrategy
@@ -71,20 +97,55 @@ func WithMaxEntrySize(v uint32) Option {
 		return WithMaxEntrySize(previous)
 	}
 }
-func WithHasher(v Hasher) Option {
+func WithMaxCost(v int64) Option {
+	return func(cc *Options) Option {
+		previous := cc.MaxCost
+		cc.MaxCost = v
+		return WithMaxCost(previous)
+	}
+}
+func WithCostFunc(v CostFunc[interface{}, interface{}]) Option {
+	return func(cc *Options) Option {
+		previous := cc.CostFunc
+		cc.CostFunc = v
+		return WithCostFunc(previous)
+	}
+}
+func WithHasher(v Hasher[interface{}]) Option {
 	return func(cc *Options) Option {
 		previous := cc.Hasher
 		cc.Hasher = v
 		return WithHasher(previous)
 	}
 }
-func WithOnRemoveCallbackFunc(v cache.EvictCallback) Option {
+func WithOnRemoveCallbackFunc(v cache.EvictCallback[interface{}, interface{}]) Option {
 	return func(cc *Options) Option {
 		previous := cc.OnRemoveCallbackFunc
 		cc.OnRemoveCallbackFunc = v
 		return WithOnRemoveCallbackFunc(previous)
 	}
 }
+func WithCallbackQueueSize(v int) Option {
+	return func(cc *Options) Option {
+		previous := cc.CallbackQueueSize
+		cc.CallbackQueueSize = v
+		return WithCallbackQueueSize(previous)
+	}
+}
+func WithCallbackOverflowPolicy(v OverflowPolicy) Option {
+	return func(cc *Options) Option {
+		previous := cc.CallbackOverflowPolicy
+		cc.CallbackOverflowPolicy = v
+		return WithCallbackOverflowPolicy(previous)
+	}
+}
+func WithClearOnClose(v bool) Option {
+	return func(cc *Options) Option {
+		previous := cc.ClearOnClose
+		cc.ClearOnClose = v
+		return WithClearOnClose(previous)
+	}
+}
 func WithDevelopment(v bool) Option {
 	return func(cc *Options) Option {
 		previous := cc.Development
@@ -92,6 +153,34 @@ func WithDevelopment(v bool) Option {
 		return WithDevelopment(previous)
 	}
 }
+func WithLoader(v LoaderFunc[interface{}, interface{}]) Option {
+	return func(cc *Options) Option {
+		previous := cc.Loader
+		cc.Loader = v
+		return WithLoader(previous)
+	}
+}
+func WithLoadTimeout(v time.Duration) Option {
+	return func(cc *Options) Option {
+		previous := cc.LoadTimeout
+		cc.LoadTimeout = v
+		return WithLoadTimeout(previous)
+	}
+}
+func WithRefreshAfterWrite(v time.Duration) Option {
+	return func(cc *Options) Option {
+		previous := cc.RefreshAfterWrite
+		cc.RefreshAfterWrite = v
+		return WithRefreshAfterWrite(previous)
+	}
+}
+func WithCodec(v Codec) Option {
+	return func(cc *Options) Option {
+		previous := cc.Codec
+		cc.Codec = v
+		return WithCodec(previous)
+	}
+}
 func WithLogger(v Logger) Option {
 	return func(cc *Options) Option {
 		previous := cc.Logger
@@ -99,6 +188,13 @@ func WithLogger(v Logger) Option {
 		return WithLogger(previous)
 	}
 }
+func WithLogLevel(v LogLevel) Option {
+	return func(cc *Options) Option {
+		previous := cc.LogLevel
+		cc.LogLevel = v
+		return WithLogLevel(previous)
+	}
+}
 
 func NewOptions(opts ...Option) *Options {
 	cc := newDefaultOptions()
@@ -124,13 +220,25 @@ func newDefaultOptions() *Options {
 	for _, opt := range [...]Option{
 		WithShards(1024),
 		WithExpiration(DefaultExpiration),
+		WithExpirationMode(ExpireAfterWrite),
+		WithAccessExpiration(0),
 		WithEvictStrategy(default_evict_strategy),
 		WithCleanInterval(30 * time.Second),
 		WithMaxEntrySize(1024 * 1024),
-		WithHasher(newDefaultHasher()),
+		WithMaxCost(0),
+		WithCostFunc(nil),
+		WithHasher(NewDefaultHasher()),
 		WithOnRemoveCallbackFunc(nil),
+		WithCallbackQueueSize(1024),
+		WithCallbackOverflowPolicy(OverflowInline),
+		WithClearOnClose(false),
 		WithDevelopment(true),
+		WithLoader(nil),
+		WithLoadTimeout(0),
+		WithRefreshAfterWrite(0),
+		WithCodec(newGobCodec()),
 		WithLogger(nil),
+		WithLogLevel(LogDebug),
 	} {
 		_ = opt(cc)
 	}
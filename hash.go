@@ -1,8 +1,143 @@
 package gcache
 
-// Hasher is responsible for generating unsigned, 64 bit hash of provided string. Hasher should minimize collisions
-// (generating same hash for different strings) and while performance is also important fast functions are preferable (i.e.
+import (
+	"hash/maphash"
+	"reflect"
+	"unsafe"
+)
+
+// hashSeed seeds the hashes of the keys hashed with hash/maphash, it differs from one process to another.
+var hashSeed = maphash.MakeSeed()
+
+// Hasher is responsible for generating unsigned, 64 bit hash of provided key. Hasher should minimize collisions
+// (generating same hash for different keys) and while performance is also important fast functions are preferable (i.e.
 // you can use FarmHash family).
-type Hasher interface {
-	Sum64(interface{}) uint64
+type Hasher[K comparable] interface {
+	Sum64(K) uint64
+}
+
+// Hashable is implemented by keys which hash themselves, the default Hasher uses Hash64 when available.
+// Equal keys must return the same hash.
+type Hashable interface {
+	Hash64() uint64
+}
+
+// WithKeyHasher sets the Hasher of a cache created by New with the key type K,
+// it is used instead of the Hasher option so that keys are hashed without being boxed.
+func WithKeyHasher[K comparable](h Hasher[K]) Option {
+	return WithHasher(keyHasher[K]{h})
+}
+
+// keyHasher carries a Hasher[K] through the Hasher option.
+type keyHasher[K comparable] struct {
+	h Hasher[K]
+}
+
+func (k keyHasher[K]) Sum64(key interface{}) uint64 {
+	return k.h.Sum64(key.(K))
+}
+
+// hasherFunc adapts a function to a Hasher.
+type hasherFunc[K comparable] func(K) uint64
+
+func (f hasherFunc[K]) Sum64(key K) uint64 {
+	return f(key)
+}
+
+// mayHoldUnhashable reports whether the values of type t may not be usable as map keys although t is
+// comparable, holding a slice, map or function in an interface.
+func mayHoldUnhashable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Array:
+		return mayHoldUnhashable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if mayHoldUnhashable(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hashable reports whether the key can be stored, logging the keys which would make the strategies panic.
+func (c *Cache[K, V]) hashable(key K) bool {
+	if !c.checkKeys {
+		return true
+	}
+	if k := interface{}(key); k == nil || reflect.ValueOf(k).Comparable() {
+		return true
+	}
+	c.logger.Errorf("cache %s: key %v of type %T is not hashable, it is ignored", c.name, key, key)
+	return false
+}
+
+// hasherOf returns the Hasher of K for the Hasher option h: the one given by WithKeyHasher,
+// the default hasher specialized for K, or h called with boxed keys.
+func hasherOf[K comparable](h Hasher[interface{}]) Hasher[K] {
+	switch t := h.(type) {
+	case keyHasher[K]:
+		return t.h
+	case fnv64a:
+		if kh := newFNVKeyHasher[K](); kh != nil {
+			return kh
+		}
+	case mapHasher:
+		if kh := newMapKeyHasher[K](); kh != nil {
+			return kh
+		}
+	}
+	if kh, ok := h.(Hasher[K]); ok {
+		return kh
+	}
+	return hasherFunc[K](func(key K) uint64 {
+		return h.Sum64(key)
+	})
+}
+
+// newFNVKeyHasher returns the FNV hasher specialized for K, which reads the key in place instead of going
+// through the type switch of fnv64a: Hash64 for Hashable keys, fnv for strings, the value of integers, and
+// hash/maphash for the other comparable kinds. It returns nil for interface types.
+func newFNVKeyHasher[K comparable]() Hasher[K] {
+	var f fnv64a
+	t := reflect.TypeOf((*K)(nil)).Elem()
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if t.Implements(reflect.TypeOf((*Hashable)(nil)).Elem()) {
+		return hasherFunc[K](func(key K) uint64 {
+			return interface{}(key).(Hashable).Hash64()
+		})
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return hasherFunc[K](func(key K) uint64 {
+			return f.hash(*(*string)(unsafe.Pointer(&key)))
+		})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch t.Size() {
+		case 8:
+			return hasherFunc[K](func(key K) uint64 {
+				return *(*uint64)(unsafe.Pointer(&key))
+			})
+		case 4:
+			return hasherFunc[K](func(key K) uint64 {
+				return uint64(*(*uint32)(unsafe.Pointer(&key)))
+			})
+		case 2:
+			return hasherFunc[K](func(key K) uint64 {
+				return uint64(*(*uint16)(unsafe.Pointer(&key)))
+			})
+		case 1:
+			return hasherFunc[K](func(key K) uint64 {
+				return uint64(*(*uint8)(unsafe.Pointer(&key)))
+			})
+		}
+	}
+	return hasherFunc[K](func(key K) uint64 {
+		return maphash.Comparable(hashSeed, key)
+	})
 }
@@ -0,0 +1,41 @@
+package gcache
+
+import (
+	"iter"
+	"time"
+)
+
+// Range calls fn for the live entries of the cache until fn returns false.
+//
+// The shards are walked one at a time: the live entries of a shard are copied under its lock, which is
+// released before fn is called, so fn may use the cache. Range is therefore not a snapshot of the whole
+// cache: an entry written or removed during the walk may or may not be seen, an entry seen may have been
+// removed or updated since it was copied, and no key is seen twice. Entries expired when their shard is
+// copied are skipped.
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	if !c.begin() {
+		return
+	}
+	defer c.end()
+	for _, shard := range c.shards {
+		for _, e := range shard.snapshot(time.Now().UnixNano()) {
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the live entries of the cache, with the consistency of Range.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return c.Range
+}
+
+// Keys returns an iterator over the keys of the live entries of the cache, with the consistency of Range.
+func (c *Cache[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		c.Range(func(key K, _ V) bool {
+			return yield(key)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package gcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LoaderFunc loads the value of a key missing from the cache, see Cache.GetOrLoad.
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// loaderOf adapts the Loader option to the key and value types of a cache.
+func loaderOf[K comparable, V any](l LoaderFunc[interface{}, interface{}]) LoaderFunc[K, V] {
+	if l == nil {
+		return nil
+	}
+	if kl, ok := interface{}(l).(LoaderFunc[K, V]); ok {
+		return kl
+	}
+	return func(ctx context.Context, key K) (V, error) {
+		var value V
+		v, err := l(ctx, key)
+		if err != nil || v == nil {
+			return value, err
+		}
+		value, ok := v.(V)
+		if !ok {
+			return value, fmt.Errorf("gcache: loader returned %T for key %v, want %T", v, key, value)
+		}
+		return value, nil
+	}
+}
+
+// loadCall is an in-flight or completed LoaderFunc call shared by all the callers missing the same key.
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// wait blocks until the call completes or ctx is done.
+func (call *loadCall[V]) wait(ctx context.Context) (V, error) {
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// loadGroup coalesces the concurrent loads of a shard, one call per key.
+type loadGroup[K comparable, V any] struct {
+	lock  sync.Mutex
+	calls map[K]*loadCall[V]
+}
+
+// load returns the value of the key from the shard, calling loader once for all concurrent callers and storing
+// its result into the shard. The cache is checked again once the group is locked, so that a caller missing the key
+// right before another call completed does not load it twice, unless reload asks to replace a cached value.
+// The loader runs in its own goroutine with a context detached from the callers, see LoadTimeout, and each caller
+// waits for it until its own ctx is done.
+func (c *Cache[K, V]) load(ctx context.Context, s *cacheShard[K, V], key K, loader LoaderFunc[K, V], reload bool) (V, error) {
+	s.loads.lock.Lock()
+	if call, ok := s.loads.calls[key]; ok {
+		s.loads.lock.Unlock()
+		return call.wait(ctx)
+	}
+	// lookup removes nothing, a removal callback delivered under the group lock could load a key of the shard.
+	if value, ok := s.lookup(key); ok && !reload {
+		s.loads.lock.Unlock()
+		return value, nil
+	}
+	// the call is an operation of its own, Close waits for it after the callers gave up.
+	if !c.begin() {
+		s.loads.lock.Unlock()
+		var zero V
+		return zero, ErrClosed
+	}
+	call := &loadCall[V]{done: make(chan struct{})}
+	s.loads.calls[key] = call
+	s.loads.lock.Unlock()
+
+	go c.call(context.WithoutCancel(ctx), s, key, loader, call, reload)
+	return call.wait(ctx)
+}
+
+// call runs loader for the waiters of call, a panic of the loader is logged and returned to them as an error.
+// The loaded value of a reload replaces the cached one in place, see cacheShard.reload.
+func (c *Cache[K, V]) call(ctx context.Context, s *cacheShard[K, V], key K, loader LoaderFunc[K, V], call *loadCall[V], reload bool) {
+	defer c.end()
+	if c.cc.LoadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cc.LoadTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(c.logger, r)
+			call.err = fmt.Errorf("%w: panic: %v", ErrLoaderFailed, r)
+			s.finishLoad(key, call)
+		}
+	}()
+	start := time.Now()
+	call.value, call.err = loader(ctx, key)
+	s.stats.load(time.Since(start), call.err)
+	if call.err != nil {
+		call.err = fmt.Errorf("%w: %w", ErrLoaderFailed, call.err)
+	}
+	switch {
+	case call.err != nil:
+	case reload:
+		s.reload(key, call.value, c.cc.Expiration)
+	default:
+		s.set(key, call.value, c.cc.Expiration)
+	}
+	s.finishLoad(key, call)
+}
+
+// reload replaces the value of a refreshed key. A live entry keeps its expiration, as with update, and is due
+// for its next refresh; one expired or removed meanwhile is added again with ttl. A value heavier than the
+// budget of the shard leaves the entry unchanged, as add does.
+func (s *cacheShard[K, V]) reload(key K, value V, ttl time.Duration) {
+	s.lock.Lock()
+	defer s.unlock()
+	e, found := s.cache.GetEntry(key)
+	if !found || e.Expired(time.Now().UnixNano()) {
+		s.add(key, value, ttl, s.costOf(key, value))
+		return
+	}
+	cost := s.costOf(key, value)
+	if s.costs != nil && cost > s.maxCost {
+		return
+	}
+	e.Value = value
+	s.stats.set()
+	if s.refresh > 0 {
+		e.RefreshAt = time.Now().Add(s.refresh).UnixNano()
+	}
+	if s.costs == nil {
+		return
+	}
+	s.cost += cost - s.costs[key]
+	s.costs[key] = cost
+	for s.cost > s.maxCost && s.cache.Len() > 0 {
+		s.cache.RemoveOldest()
+	}
+}
+
+func (s *cacheShard[K, V]) finishLoad(key K, call *loadCall[V]) {
+	s.loads.lock.Lock()
+	delete(s.loads.calls, key)
+	s.loads.lock.Unlock()
+	close(call.done)
+}
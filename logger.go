@@ -1,8 +1,10 @@
 package gcache
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 )
 
@@ -45,13 +47,90 @@ func (d *dl) Fatalf(format string, v ...interface{}) {
 	os.Exit(1)
 }
 
-func setLogger(logger Logger) {
+// L returns the default Logger, used by the caches created without the Logger option.
+func L() Logger {
+	return l
+}
+
+// LogLevel is the lowest level of the messages a cache logs.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+// levelLogger drops the messages of the Logger below its level, fatal messages are always logged.
+type levelLogger struct {
+	Logger
+	level LogLevel
+}
+
+// newLevelLogger returns the logger of a cache, the default Logger if logger is nil.
+func newLevelLogger(logger Logger, level LogLevel) Logger {
 	if logger == nil {
-		return
+		logger = l
+	}
+	if level <= LogDebug {
+		return logger
 	}
-	l = logger
+	return levelLogger{Logger: logger, level: level}
 }
 
-func L() Logger {
-	return l
+func (ll levelLogger) Debugf(format string, v ...interface{}) {
+	if ll.level <= LogDebug {
+		ll.Logger.Debugf(format, v...)
+	}
+}
+func (ll levelLogger) Infof(format string, v ...interface{}) {
+	if ll.level <= LogInfo {
+		ll.Logger.Infof(format, v...)
+	}
+}
+func (ll levelLogger) Warnf(format string, v ...interface{}) {
+	if ll.level <= LogWarn {
+		ll.Logger.Warnf(format, v...)
+	}
+}
+func (ll levelLogger) Errorf(format string, v ...interface{}) {
+	if ll.level <= LogError {
+		ll.Logger.Errorf(format, v...)
+	}
+}
+
+// slogLogger adapts a slog.Logger to the Logger interface.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger writing to logger, with the message formatted from format and v.
+// Fatalf logs at the error level, then exits.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+func (s slogLogger) log(level slog.Level, format string, v ...interface{}) {
+	ctx := context.Background()
+	if s.logger.Enabled(ctx, level) {
+		s.logger.Log(ctx, level, fmt.Sprintf(format, v...))
+	}
+}
+
+func (s slogLogger) Debugf(format string, v ...interface{}) {
+	s.log(slog.LevelDebug, format, v...)
+}
+func (s slogLogger) Infof(format string, v ...interface{}) {
+	s.log(slog.LevelInfo, format, v...)
+}
+func (s slogLogger) Warnf(format string, v ...interface{}) {
+	s.log(slog.LevelWarn, format, v...)
+}
+func (s slogLogger) Errorf(format string, v ...interface{}) {
+	s.log(slog.LevelError, format, v...)
+}
+func (s slogLogger) Fatalf(format string, v ...interface{}) {
+	s.log(slog.LevelError, format, v...)
+	os.Exit(1)
 }
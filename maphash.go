@@ -0,0 +1,104 @@
+package gcache
+
+import (
+	"hash/maphash"
+	"reflect"
+	"unsafe"
+)
+
+// NewDefaultHasher returns the default Hasher: strings and other comparable keys are hashed
+// with hash/maphash, integers and the hash of Hashable keys are mixed with the murmur3 finalizer so that
+// sequential or strided keys are spread over the shards. Hashes differ from one process to another.
+func NewDefaultHasher() Hasher[interface{}] {
+	return mapHasher{}
+}
+
+type mapHasher struct{}
+
+func (mapHasher) Sum64(key interface{}) uint64 {
+	switch t := key.(type) {
+	case string:
+		return maphash.String(hashSeed, t)
+	case Hashable:
+		return mix64(t.Hash64())
+	case int:
+		return mix64(uint64(t))
+	case int8:
+		return mix64(uint64(t))
+	case int16:
+		return mix64(uint64(t))
+	case int32:
+		return mix64(uint64(t))
+	case int64:
+		return mix64(uint64(t))
+	case uint:
+		return mix64(uint64(t))
+	case uint8:
+		return mix64(uint64(t))
+	case uint16:
+		return mix64(uint64(t))
+	case uint32:
+		return mix64(uint64(t))
+	case uint64:
+		return mix64(t)
+	default:
+		if key == nil || !reflect.TypeOf(key).Comparable() {
+			// the Cache rejects such keys before hashing them.
+			return 0
+		}
+		return maphash.Comparable(hashSeed, key)
+	}
+}
+
+// mix64 is the finalizer of murmur3, every bit of v affects every bit of the result.
+func mix64(v uint64) uint64 {
+	v ^= v >> 33
+	v *= 0xff51afd7ed558ccd
+	v ^= v >> 33
+	v *= 0xc4ceb9fe1a85ec53
+	v ^= v >> 33
+	return v
+}
+
+// newMapKeyHasher returns the default hasher specialized for K, which reads the key in place instead of
+// going through the type switch of mapHasher. It returns nil for interface types.
+func newMapKeyHasher[K comparable]() Hasher[K] {
+	t := reflect.TypeOf((*K)(nil)).Elem()
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if t.Implements(reflect.TypeOf((*Hashable)(nil)).Elem()) {
+		return hasherFunc[K](func(key K) uint64 {
+			return mix64(interface{}(key).(Hashable).Hash64())
+		})
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return hasherFunc[K](func(key K) uint64 {
+			return maphash.String(hashSeed, *(*string)(unsafe.Pointer(&key)))
+		})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch t.Size() {
+		case 8:
+			return hasherFunc[K](func(key K) uint64 {
+				return mix64(*(*uint64)(unsafe.Pointer(&key)))
+			})
+		case 4:
+			return hasherFunc[K](func(key K) uint64 {
+				return mix64(uint64(*(*uint32)(unsafe.Pointer(&key))))
+			})
+		case 2:
+			return hasherFunc[K](func(key K) uint64 {
+				return mix64(uint64(*(*uint16)(unsafe.Pointer(&key))))
+			})
+		case 1:
+			return hasherFunc[K](func(key K) uint64 {
+				return mix64(uint64(*(*uint8)(unsafe.Pointer(&key))))
+			})
+		}
+	}
+	return hasherFunc[K](func(key K) uint64 {
+		return maphash.Comparable(hashSeed, key)
+	})
+}
@@ -0,0 +1,99 @@
+package gcache
+
+import (
+	"bitbucket.org/funplus/gcache/cache"
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// statsProvider is a named cache whose statistics are exported by MetricsHandler.
+type statsProvider interface {
+	Stats() Stats
+}
+
+var (
+	// registry holds the open caches by name.
+	registry   = make(map[string]statsProvider)
+	registryMu sync.RWMutex
+)
+
+func registerCache(name string, c statsProvider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = c
+}
+
+// unregisterCache removes the cache from the registry, unless another cache was registered under its name since.
+func unregisterCache(name string, c statsProvider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registry[name] == c {
+		delete(registry, name)
+	}
+}
+
+type metric struct {
+	name  string
+	help  string
+	typ   string
+	value func(s Stats) float64
+}
+
+var metrics = []metric{
+	{"gcache_hits_total", "Number of reads which found the key.", "counter", func(s Stats) float64 { return float64(s.Hits) }},
+	{"gcache_misses_total", "Number of reads which did not find the key.", "counter", func(s Stats) float64 { return float64(s.Misses) }},
+	{"gcache_sets_total", "Number of entries written.", "counter", func(s Stats) float64 { return float64(s.Sets) }},
+	{"gcache_deletes_total", "Number of keys removed by Delete.", "counter", func(s Stats) float64 { return float64(s.Deletes) }},
+	{"gcache_expired_on_read_total", "Number of reads which found the key expired.", "counter", func(s Stats) float64 { return float64(s.ExpiredOnRead) }},
+	{"gcache_load_successes_total", "Number of successful loader calls.", "counter", func(s Stats) float64 { return float64(s.LoadSuccesses) }},
+	{"gcache_load_errors_total", "Number of failed loader calls.", "counter", func(s Stats) float64 { return float64(s.LoadErrors) }},
+	{"gcache_load_duration_seconds_total", "Total time spent in loader calls.", "counter", func(s Stats) float64 { return s.LoadTime.Seconds() }},
+	{"gcache_entries", "Number of entries in the cache.", "gauge", func(s Stats) float64 { return float64(s.Entries) }},
+}
+
+// MetricsHandler returns an http.Handler exporting the statistics of every open cache
+// in the Prometheus text exposition format, labelled by cache name and removal reason.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		writeMetrics(bw)
+		_ = bw.Flush()
+	})
+}
+
+func writeMetrics(w *bufio.Writer) {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	stats := make(map[string]Stats, len(registry))
+	for name, c := range registry {
+		names = append(names, name)
+		stats[name] = c.Stats()
+	}
+	registryMu.RUnlock()
+	sort.Strings(names)
+
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s{cache=\"%s\"} %v\n", m.name, escapeLabel(name), m.value(stats[name]))
+		}
+	}
+	fmt.Fprintf(w, "# HELP gcache_evictions_total Number of entries removed, by reason.\n# TYPE gcache_evictions_total counter\n")
+	for _, name := range names {
+		for reason := cache.RemoveReason(0); int(reason) < numRemoveReasons; reason++ {
+			fmt.Fprintf(w, "gcache_evictions_total{cache=\"%s\",reason=\"%s\"} %d\n",
+				escapeLabel(name), reason, stats[name].Evictions[reason])
+		}
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
@@ -4,109 +4,318 @@ import (
 	cache2 "bitbucket.org/funplus/gcache/cache"
 	"fmt"
 	"sync"
+	"time"
 )
 
-type cacheShard struct {
-	cache      cache2.ICache
+type cacheShard[K comparable, V any] struct {
+	cache      cache2.ICache[K, V]
 	lock       sync.RWMutex
 	expiration uint64
+	loads      loadGroup[K, V]
+	stats      shardCounters
+	// mode is the ExpirationMode, idle the AccessExpiration used when it combines both modes,
+	// refresh the RefreshAfterWrite.
+	mode    ExpirationMode
+	idle    time.Duration
+	refresh time.Duration
+	// removed collects the entries removed while the lock is held, delivered by unlock.
+	removed    []removal[K, V]
+	dispatcher *dispatcher[K, V]
+	// maxCost is the weight budget of the shard, zero means no limit and no weight tracking.
+	maxCost  int64
+	cost     int64
+	costs    map[K]int64
+	costFunc CostFunc[K, V]
+	// noRoom is set when Resize gave the shard no capacity.
+	noRoom bool
 }
 
 const minimumEntriesInShard = 10
 
-func initNewShard(c *GCache) (*cacheShard, error) {
+func initNewShard[K comparable, V any](c *Cache[K, V]) (*cacheShard[K, V], error) {
 	opts := c.cc
-	size := max(opts.MaxEntrySize/uint32(opts.Shards), minimumEntriesInShard)
-	cacheBuilder := cache2.Get(opts.EvictStrategy)
+	size := shardSize(opts.MaxEntrySize, opts.Shards)
+	cacheBuilder := newBuilder[K, V](opts.EvictStrategy, c.hasher.Sum64)
 	if cacheBuilder == nil {
 		return nil, fmt.Errorf("gcache: cache unregistered %s", opts.EvictStrategy)
 	}
-	cacheImpl := cacheBuilder.Build(size, opts.Expiration, c.evictCallback)
-	shard := &cacheShard{
-		cache:      cacheImpl,
+	shard := &cacheShard[K, V]{
 		expiration: uint64(opts.Expiration.Seconds()),
+		mode:       opts.ExpirationMode,
+		idle:       opts.AccessExpiration,
+		refresh:    opts.RefreshAfterWrite,
+		loads:      loadGroup[K, V]{calls: make(map[K]*loadCall[V])},
+		dispatcher: c.dispatcher,
 	}
+	if opts.MaxCost > 0 {
+		shard.maxCost = opts.MaxCost / int64(opts.Shards)
+		if shard.maxCost < 1 {
+			shard.maxCost = 1
+		}
+		shard.costs = make(map[K]int64)
+		shard.costFunc = costFuncOf[K, V](opts.CostFunc)
+	}
+	shard.cache = cacheBuilder.Build(size, opts.Expiration, func(key K, value V, reason cache2.RemoveReason) {
+		if shard.costs != nil {
+			shard.cost -= shard.costs[key]
+			delete(shard.costs, key)
+		}
+		shard.stats.evict(reason)
+		c.evictCallback(key, value, reason)
+		if shard.dispatcher != nil {
+			shard.removed = append(shard.removed, removal[K, V]{key: key, value: value, reason: reason})
+		}
+	})
 	return shard, nil
 }
 
-// Get looks up a key's value from the cache.
-func (s *cacheShard) get(key interface{}) (value interface{}, ok bool) {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-	value, ok = s.cache.Get(key)
+// shardSize returns the number of entries of each of the shards holding maxEntries.
+func shardSize(maxEntries uint32, shards int32) uint32 {
+	return max(maxEntries/uint32(shards), minimumEntriesInShard)
+}
+
+// unlock releases the write lock, then delivers the entries removed while it was held.
+func (s *cacheShard[K, V]) unlock() {
+	removed := s.removed
+	s.removed = nil
+	s.lock.Unlock()
+	if len(removed) > 0 {
+		s.dispatcher.dispatch(removed)
+	}
+}
+
+// Get looks up a key's value from the cache, stale reports whether it is due for a refresh.
+// An entry is reported stale once per RefreshAfterWrite, until it is written again.
+// It takes the write lock since strategies reorder their entries on access.
+func (s *cacheShard[K, V]) get(key K) (value V, ok, stale bool) {
+	s.lock.Lock()
+	defer s.unlock()
+	value, ok = s.read(key)
+	stale = ok && s.stale(key)
 	return
 }
 
+// stale reports whether the entry of the key is due for a refresh, pushing its RefreshAt back if so.
+// The lock must be held.
+func (s *cacheShard[K, V]) stale(key K) bool {
+	if s.refresh <= 0 {
+		return false
+	}
+	if e, found := s.cache.GetEntry(key); found && e.RefreshAt > 0 {
+		now := time.Now().UnixNano()
+		if now > e.RefreshAt {
+			e.RefreshAt = now + int64(s.refresh)
+			return true
+		}
+	}
+	return false
+}
+
+// read gets the value of the key from the strategy, counting a hit or a miss, and whether the miss
+// removed the key for being expired. The lock must be held.
+func (s *cacheShard[K, V]) read(key K) (value V, ok bool) {
+	value, err := s.readE(key)
+	return value, err == nil
+}
+
+// readE is read returning ErrNotFound or ErrExpired on a miss. The strategies only miss a resident entry
+// when it expired, which they remove.
+func (s *cacheShard[K, V]) readE(key K) (value V, err error) {
+	_, resident := s.cache.GetEntry(key)
+	value, ok := s.cache.Get(key)
+	if ok {
+		s.stats.hit()
+		return value, nil
+	}
+	s.stats.miss()
+	if resident {
+		s.stats.expireOnRead()
+		return value, ErrExpired
+	}
+	return value, ErrNotFound
+}
+
 // Peek returns key's value without updating the "recently used and timestamp"-ness of the key.
-func (s *cacheShard) peek(key interface{}) (value interface{}, ok bool) {
+// It takes the write lock since an expired entry is removed.
+func (s *cacheShard[K, V]) peek(key K) (value V, ok bool) {
+	s.lock.Lock()
+	defer s.unlock()
+	value, ok = s.cache.Peek(key)
+	return
+}
+
+// lookup returns the value of a live entry under the read lock. Unlike peek, it leaves an expired entry
+// to be removed later, so that it never delivers removal callbacks.
+func (s *cacheShard[K, V]) lookup(key K) (value V, ok bool) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
-	value, ok = s.cache.Peek(key)
+	if e, found := s.cache.GetEntry(key); found && !e.Expired(time.Now().UnixNano()) {
+		return e.Value, true
+	}
 	return
 }
 
-// Add adds a value to the cache. Returns true if an eviction occurred.
-func (s *cacheShard) set(key, value interface{}) (ok bool) {
+// Add adds a value to the cache which expires after ttl. Returns true if an eviction occurred.
+func (s *cacheShard[K, V]) set(key K, value V, ttl time.Duration) (ok bool) {
 	s.lock.Lock()
-	defer s.lock.Unlock()
-	ok = s.cache.Add(key, value)
-	return
+	defer s.unlock()
+	return s.add(key, value, ttl, s.costOf(key, value))
 }
 
-func (s *cacheShard) loadOrStore(key interface{}, newValue interface{}) (value interface{}, ok bool) {
+// setWithCost is set with the weight of the entry given instead of computed by the CostFunc.
+func (s *cacheShard[K, V]) setWithCost(key K, value V, ttl time.Duration, cost int64) (ok bool) {
 	s.lock.Lock()
-	defer s.lock.Unlock()
-	value, ok = s.cache.Get(key)
+	defer s.unlock()
+	return s.add(key, value, ttl, cost)
+}
+
+// add adds the entry with its weight, then evicts through the strategy until the shard is within its budget.
+// An entry heavier than the whole budget, or any entry when the shard has no capacity, is rejected, leaving
+// the shard and the current entry of the key unchanged, and add returns false. The lock must be held.
+func (s *cacheShard[K, V]) add(key K, value V, ttl time.Duration, cost int64) bool {
+	if s.noRoom || (s.costs != nil && cost > s.maxCost) {
+		return false
+	}
+	ok := s.addWithTTL(key, value, ttl)
+	s.stats.set()
+	if s.refresh > 0 {
+		if e, found := s.cache.GetEntry(key); found {
+			e.RefreshAt = time.Now().Add(s.refresh).UnixNano()
+		}
+	}
+	if s.costs == nil {
+		return ok
+	}
+	// the strategy may have refused the entry right away.
+	if _, present := s.cache.GetEntry(key); !present {
+		return ok
+	}
+	s.cost += cost - s.costs[key]
+	s.costs[key] = cost
+	for s.cost > s.maxCost && s.cache.Len() > 0 {
+		s.cache.RemoveOldest()
+	}
+	return ok
+}
+
+// addWithTTL adds the entry to the strategy, setting how long it may stay idle under ExpireAfterAccess.
+// The entry is added with its first deadline, reads then push it back through cache.Entry.Touch.
+func (s *cacheShard[K, V]) addWithTTL(key K, value V, ttl time.Duration) bool {
+	if s.mode&ExpireAfterAccess == 0 {
+		return s.cache.AddWithTTL(key, value, ttl)
+	}
+	idle, writeDeadline := ttl, int64(0)
+	if s.mode&ExpireAfterWrite != 0 {
+		if s.idle <= 0 {
+			return s.cache.AddWithTTL(key, value, ttl)
+		}
+		idle, writeDeadline = s.idle, cache2.Deadline(ttl)
+		if ttl <= 0 || ttl > s.idle {
+			ttl = s.idle
+		}
+	}
+	ok := s.cache.AddWithTTL(key, value, ttl)
+	if e, found := s.cache.GetEntry(key); found {
+		e.Idle, e.WriteDeadline = idle, writeDeadline
+	}
+	return ok
+}
+
+// costOf returns the weight of the entry given by the CostFunc, zero when weights are not tracked.
+func (s *cacheShard[K, V]) costOf(key K, value V) int64 {
+	if s.costFunc == nil {
+		return 0
+	}
+	return s.costFunc(key, value)
+}
+
+func (s *cacheShard[K, V]) loadOrStore(key K, newValue V, ttl time.Duration) (value V, ok bool) {
+	s.lock.Lock()
+	defer s.unlock()
+	value, ok = s.read(key)
 	if ok {
 		return
 	}
-	s.cache.Add(key, newValue)
+	s.add(key, newValue, ttl, s.costOf(key, newValue))
 	return
 }
 
-func (s *cacheShard) compareAndSet(key interface{}, expect, update interface{}, equal func(old, new interface{}) bool) (interface{}, bool) {
+func (s *cacheShard[K, V]) compareAndSet(key K, expect, update V, ttl time.Duration, equal func(old, new V) bool) (V, bool) {
 	s.lock.Lock()
-	defer s.lock.Unlock()
-	v, ok := s.cache.Get(key)
-	if !ok || equal(v, expect) {
-		s.cache.Add(key, update)
+	defer s.unlock()
+	v, ok := s.read(key)
+	if (!ok || equal(v, expect)) && s.add(key, update, ttl, s.costOf(key, update)) {
 		return update, true
 	}
 	return v, false
 }
 
 // Remove removes the provided key from the cache.
-func (s *cacheShard) remove(key interface{}) (present bool) {
+func (s *cacheShard[K, V]) remove(key K) (present bool) {
 	s.lock.Lock()
-	defer s.lock.Unlock()
+	defer s.unlock()
 	present = s.cache.Remove(key)
+	if present {
+		s.stats.delete()
+	}
 	return
 }
 
 // Contains checks if a key is in the cache, without updating the
-// recent-ness or deleting it for being stale.
-func (s *cacheShard) contains(key interface{}) bool {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
+// recent-ness, removing it if expired.
+func (s *cacheShard[K, V]) contains(key K) bool {
+	s.lock.Lock()
+	defer s.unlock()
 	return s.cache.Contains(key)
 }
 
-// Count returns the number of items in the cache.
-func (s *cacheShard) count() int {
+// Count returns the number of items in the cache, the expired ones are removed first.
+func (s *cacheShard[K, V]) count() int {
+	s.lock.Lock()
+	defer s.unlock()
+	s.cache.CleanUp(time.Now().UnixNano())
+	return s.cache.Len()
+}
+
+// len returns the number of entries under the read lock, the expired ones not removed yet included.
+func (s *cacheShard[K, V]) len() int {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 	return s.cache.Len()
 }
 
 // RemoveOldest removes the oldest item from the cache.
-func (s *cacheShard) removeOldest() {
+func (s *cacheShard[K, V]) removeOldest() {
 	s.lock.Lock()
-	defer s.lock.Unlock()
+	defer s.unlock()
 	s.cache.RemoveOldest()
 }
 
-func (s *cacheShard) cleanUp(currentTimestamp int64) {
+func (s *cacheShard[K, V]) cleanUp(currentTimestamp int64) {
 	s.lock.Lock()
-	defer s.lock.Unlock()
+	defer s.unlock()
 	s.cache.CleanUp(currentTimestamp)
 }
+
+// purge removes all the entries of the shard.
+func (s *cacheShard[K, V]) purge() {
+	s.lock.Lock()
+	defer s.unlock()
+	s.cache.Clear()
+}
+
+// resize changes the number of entries of the shard, returning the number of entries evicted.
+// A size of zero, which means no limit to the strategies, evicts all the entries and refuses the next ones.
+func (s *cacheShard[K, V]) resize(size uint32) int {
+	s.lock.Lock()
+	defer s.unlock()
+	s.noRoom = size == 0
+	if s.noRoom {
+		evicted := s.cache.Len()
+		for s.cache.Len() > 0 {
+			s.cache.RemoveOldest()
+		}
+		return evicted
+	}
+	return s.cache.Resize(int(size))
+}
@@ -0,0 +1,270 @@
+package gcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	cache2 "bitbucket.org/funplus/gcache/cache"
+)
+
+const (
+	snapshotMagic   = "GCSNAP"
+	snapshotVersion = 2
+
+	recordEnd   = 0
+	recordEntry = 1
+
+	// maxSnapshotField bounds the length of an encoded key or value read from a snapshot.
+	maxSnapshotField = 1 << 30
+)
+
+// Codec encodes the keys and values of a snapshot, see Cache.SaveTo.
+type Codec interface {
+	// Marshal encodes the value pointed to by v.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into the value pointed to by v.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// gobCodec is the default Codec. Concrete types stored in interface keys or values must be registered with gob.Register.
+type gobCodec struct{}
+
+func newGobCodec() Codec {
+	return gobCodec{}
+}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// snapshotEntry is an entry copied out of a shard.
+type snapshotEntry[K comparable, V any] struct {
+	key           K
+	value         V
+	deadline      int64
+	idle          time.Duration
+	writeDeadline int64
+}
+
+// snapshot copies the live entries of the shard, from oldest to newest as given by ICache.Keys.
+// An entry reaching its deadline at currentTimestamp is left out, it has no time left to save.
+func (s *cacheShard[K, V]) snapshot(currentTimestamp int64) []snapshotEntry[K, V] {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	keys := s.cache.Keys()
+	entries := make([]snapshotEntry[K, V], 0, len(keys))
+	for _, key := range keys {
+		e, ok := s.cache.GetEntry(key)
+		if !ok || (e.Timestamp > 0 && e.Timestamp <= currentTimestamp) {
+			continue
+		}
+		entries = append(entries, snapshotEntry[K, V]{
+			key:           e.Key,
+			value:         e.Value,
+			deadline:      e.Timestamp,
+			idle:          e.Idle,
+			writeDeadline: e.WriteDeadline,
+		})
+	}
+	return entries
+}
+
+// SaveTo writes all live entries of the cache to w: their key and value encoded by the Codec option, and their
+// remaining ttl. Shards are copied one at a time, each under its lock, in the order of their strategy.
+//
+// The format is the magic "GCSNAP", a version byte and the time of the snapshot, followed by
+// one record per entry (a record type byte, the length-prefixed key and value, the remaining ttl in nanoseconds,
+// zero for no expiration, then the idle time and the time left to the write deadline of an entry expiring after
+// access, zero when unset) and an end record.
+func (c *Cache[K, V]) SaveTo(w io.Writer) error {
+	if !c.begin() {
+		return ErrClosed
+	}
+	defer c.end()
+	bw := bufio.NewWriter(w)
+	now := time.Now().UnixNano()
+	header := make([]byte, 0, len(snapshotMagic)+1+binary.MaxVarintLen64)
+	header = append(header, snapshotMagic...)
+	header = append(header, snapshotVersion)
+	header = binary.AppendVarint(header, now)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, 4*binary.MaxVarintLen64)
+	for _, shard := range c.shards {
+		for _, e := range shard.snapshot(now) {
+			key, err := c.cc.Codec.Marshal(&e.key)
+			if err != nil {
+				return fmt.Errorf("gcache: encode key %v: %w", e.key, err)
+			}
+			value, err := c.cc.Codec.Marshal(&e.value)
+			if err != nil {
+				return fmt.Errorf("gcache: encode value of key %v: %w", e.key, err)
+			}
+			var ttl, writeTTL int64
+			if e.deadline > 0 {
+				ttl = e.deadline - now
+			}
+			if e.writeDeadline > 0 {
+				writeTTL = e.writeDeadline - now
+			}
+			// errors of bufio.Writer are sticky, the last write of the record reports them.
+			buf = append(buf[:0], recordEntry)
+			buf = binary.AppendUvarint(buf, uint64(len(key)))
+			bw.Write(buf)
+			bw.Write(key)
+			buf = binary.AppendUvarint(buf[:0], uint64(len(value)))
+			bw.Write(buf)
+			bw.Write(value)
+			buf = binary.AppendVarint(buf[:0], ttl)
+			buf = binary.AppendVarint(buf, int64(e.idle))
+			buf = binary.AppendVarint(buf, writeTTL)
+			if _, err := bw.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	if err := bw.WriteByte(recordEnd); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadFrom reads a snapshot written by SaveTo and stores its entries, in their saved order so that the recency
+// of the entries is preserved. The time elapsed since the snapshot is taken off the remaining ttl of each entry,
+// those expired by then are skipped. An entry expiring after access gets back the idle time and the write
+// deadline it was saved with, the remaining ttl only sets its first deadline. Snapshots of version 1 carry
+// neither, their entries are stored with SetWithTTL as if newly written.
+func (c *Cache[K, V]) LoadFrom(r io.Reader) error {
+	if !c.begin() {
+		return ErrClosed
+	}
+	defer c.end()
+	br := bufio.NewReader(r)
+	header := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return fmt.Errorf("gcache: read snapshot header: %w", err)
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return errors.New("gcache: not a snapshot")
+	}
+	version := header[len(snapshotMagic)]
+	if version != 1 && version != snapshotVersion {
+		return fmt.Errorf("gcache: unsupported snapshot version %d", version)
+	}
+	savedAt, err := binary.ReadVarint(br)
+	if err != nil {
+		return fmt.Errorf("gcache: read snapshot header: %w", err)
+	}
+	elapsed := time.Now().UnixNano() - savedAt
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	for {
+		typ, err := br.ReadByte()
+		if err != nil {
+			return fmt.Errorf("gcache: read snapshot record: %w", err)
+		}
+		if typ == recordEnd {
+			return nil
+		}
+		if typ != recordEntry {
+			return fmt.Errorf("gcache: unknown snapshot record %d", typ)
+		}
+		keyData, err := readBytes(br)
+		if err != nil {
+			return fmt.Errorf("gcache: read snapshot key: %w", err)
+		}
+		valueData, err := readBytes(br)
+		if err != nil {
+			return fmt.Errorf("gcache: read snapshot value: %w", err)
+		}
+		ttl, err := binary.ReadVarint(br)
+		if err != nil {
+			return fmt.Errorf("gcache: read snapshot ttl: %w", err)
+		}
+		var idle, writeTTL int64
+		if version > 1 {
+			if idle, err = binary.ReadVarint(br); err == nil {
+				writeTTL, err = binary.ReadVarint(br)
+			}
+			if err != nil {
+				return fmt.Errorf("gcache: read snapshot ttl: %w", err)
+			}
+		}
+		// zero is no expiration, a negative ttl is never written.
+		if ttl < 0 || idle < 0 || writeTTL < 0 {
+			continue
+		}
+		if ttl > 0 {
+			if ttl -= elapsed; ttl <= 0 {
+				continue
+			}
+		}
+		if writeTTL > 0 {
+			if writeTTL -= elapsed; writeTTL <= 0 {
+				continue
+			}
+		}
+		var (
+			key   K
+			value V
+		)
+		if err := c.cc.Codec.Unmarshal(keyData, &key); err != nil {
+			return fmt.Errorf("gcache: decode key: %w", err)
+		}
+		if err := c.cc.Codec.Unmarshal(valueData, &value); err != nil {
+			return fmt.Errorf("gcache: decode value of key %v: %w", key, err)
+		}
+		if !c.hashable(key) {
+			continue
+		}
+		c.getShard(key).restore(key, value, time.Duration(ttl), time.Duration(idle), time.Duration(writeTTL))
+	}
+}
+
+// restore adds an entry of a snapshot which expires after ttl. Under ExpireAfterAccess, a positive idle replaces
+// the idle time addWithTTL derives from ttl and writeTTL, when positive, sets the write deadline.
+func (s *cacheShard[K, V]) restore(key K, value V, ttl, idle, writeTTL time.Duration) {
+	s.lock.Lock()
+	defer s.unlock()
+	s.add(key, value, ttl, s.costOf(key, value))
+	if s.mode&ExpireAfterAccess == 0 || idle <= 0 {
+		return
+	}
+	if e, found := s.cache.GetEntry(key); found {
+		e.Idle, e.WriteDeadline = idle, cache2.Deadline(writeTTL)
+	}
+}
+
+// readBytes reads a length-prefixed key or value, refusing a length over maxSnapshotField before allocating it.
+func readBytes(br *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxSnapshotField {
+		return nil, fmt.Errorf("length %d over %d, the snapshot is corrupt", n, maxSnapshotField)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(br, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,179 @@
+package gcache
+
+import (
+	"bitbucket.org/funplus/gcache/cache"
+	"sync/atomic"
+	"time"
+)
+
+// numRemoveReasons is the number of cache.RemoveReason values.
+const numRemoveReasons = int(cache.Clear) + 1
+
+// ShardStats is a snapshot of the counters of a shard, or of the sum of them.
+type ShardStats struct {
+	// Hits is the number of reads which found the key.
+	Hits uint64
+	// Misses is the number of reads which did not find the key, including ExpiredOnRead.
+	Misses uint64
+	// Sets is the number of entries written.
+	Sets uint64
+	// Deletes is the number of keys removed by Delete.
+	Deletes uint64
+	// Evictions is the number of entries removed for each reason.
+	Evictions map[cache.RemoveReason]uint64
+	// ExpiredOnRead is the number of reads which found the key past its expiration.
+	ExpiredOnRead uint64
+	// LoadSuccesses and LoadErrors are the number of loader calls made by GetOrLoad.
+	LoadSuccesses uint64
+	LoadErrors    uint64
+	// LoadTime is the total time spent in loader calls.
+	LoadTime time.Duration
+	// Entries is the number of entries at the time of the snapshot. Unlike Count, it removes no expired entry,
+	// those not yet removed by a read or the clean up are counted.
+	Entries int
+}
+
+// HitRatio returns the ratio of reads which found the key, zero if there was no read.
+func (s ShardStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+func (s *ShardStats) add(o ShardStats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Sets += o.Sets
+	s.Deletes += o.Deletes
+	for reason, n := range o.Evictions {
+		s.Evictions[reason] += n
+	}
+	s.ExpiredOnRead += o.ExpiredOnRead
+	s.LoadSuccesses += o.LoadSuccesses
+	s.LoadErrors += o.LoadErrors
+	s.LoadTime += o.LoadTime
+	s.Entries += o.Entries
+}
+
+// Stats is a snapshot of the counters of a cache, the sum of all shards and each of them.
+// It is not updated afterwards.
+type Stats struct {
+	ShardStats
+	Shards []ShardStats
+}
+
+// shardCounters are the counters of a shard, updated atomically so that reads holding no lock can count too.
+type shardCounters struct {
+	hits          uint64
+	misses        uint64
+	sets          uint64
+	deletes       uint64
+	evictions     [numRemoveReasons]uint64
+	expiredOnRead uint64
+	loadSuccesses uint64
+	loadErrors    uint64
+	loadNanos     uint64
+	// pad keeps the counters of neighbour shards on different cache lines.
+	_ [64]byte
+}
+
+func (c *shardCounters) hit() {
+	atomic.AddUint64(&c.hits, 1)
+}
+
+func (c *shardCounters) miss() {
+	atomic.AddUint64(&c.misses, 1)
+}
+
+func (c *shardCounters) set() {
+	atomic.AddUint64(&c.sets, 1)
+}
+
+func (c *shardCounters) delete() {
+	atomic.AddUint64(&c.deletes, 1)
+}
+
+func (c *shardCounters) evict(reason cache.RemoveReason) {
+	if int(reason) < numRemoveReasons {
+		atomic.AddUint64(&c.evictions[reason], 1)
+	}
+}
+
+func (c *shardCounters) expireOnRead() {
+	atomic.AddUint64(&c.expiredOnRead, 1)
+}
+
+func (c *shardCounters) load(d time.Duration, err error) {
+	if err != nil {
+		atomic.AddUint64(&c.loadErrors, 1)
+	} else {
+		atomic.AddUint64(&c.loadSuccesses, 1)
+	}
+	atomic.AddUint64(&c.loadNanos, uint64(d))
+}
+
+func (c *shardCounters) snapshot() ShardStats {
+	s := ShardStats{
+		Hits:          atomic.LoadUint64(&c.hits),
+		Misses:        atomic.LoadUint64(&c.misses),
+		Sets:          atomic.LoadUint64(&c.sets),
+		Deletes:       atomic.LoadUint64(&c.deletes),
+		Evictions:     make(map[cache.RemoveReason]uint64, numRemoveReasons),
+		ExpiredOnRead: atomic.LoadUint64(&c.expiredOnRead),
+		LoadSuccesses: atomic.LoadUint64(&c.loadSuccesses),
+		LoadErrors:    atomic.LoadUint64(&c.loadErrors),
+		LoadTime:      time.Duration(atomic.LoadUint64(&c.loadNanos)),
+	}
+	for reason := range c.evictions {
+		s.Evictions[cache.RemoveReason(reason)] = atomic.LoadUint64(&c.evictions[reason])
+	}
+	return s
+}
+
+func (c *shardCounters) reset() {
+	atomic.StoreUint64(&c.hits, 0)
+	atomic.StoreUint64(&c.misses, 0)
+	atomic.StoreUint64(&c.sets, 0)
+	atomic.StoreUint64(&c.deletes, 0)
+	for reason := range c.evictions {
+		atomic.StoreUint64(&c.evictions[reason], 0)
+	}
+	atomic.StoreUint64(&c.expiredOnRead, 0)
+	atomic.StoreUint64(&c.loadSuccesses, 0)
+	atomic.StoreUint64(&c.loadErrors, 0)
+	atomic.StoreUint64(&c.loadNanos, 0)
+}
+
+// Stats returns a snapshot of the counters of the cache. The shards are read one after the other,
+// so the sum is not an atomic view of the cache under concurrent writes. It only reads the shards: no entry is
+// removed and no removal callback delivered.
+func (c *Cache[K, V]) Stats() Stats {
+	if !c.begin() {
+		return Stats{}
+	}
+	defer c.end()
+	stats := Stats{
+		ShardStats: ShardStats{Evictions: make(map[cache.RemoveReason]uint64, numRemoveReasons)},
+		Shards:     make([]ShardStats, len(c.shards)),
+	}
+	for i, shard := range c.shards {
+		s := shard.stats.snapshot()
+		s.Entries = shard.len()
+		stats.Shards[i] = s
+		stats.add(s)
+	}
+	return stats
+}
+
+// ResetStats zeroes the counters of the cache.
+func (c *Cache[K, V]) ResetStats() {
+	if !c.begin() {
+		return
+	}
+	defer c.end()
+	for _, shard := range c.shards {
+		shard.stats.reset()
+	}
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache"
+	"bitbucket.org/funplus/gcache/cache/ARC"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func Test_ARCCache(t *testing.T) {
+	Convey("new ARC gCache test", t, func() {
+		gcache, err := gcache.NewGCache("test_arc",
+			gcache.WithShards(1),
+			gcache.WithMaxEntrySize(10),
+			gcache.WithEvictStrategy(ARC.Name))
+		So(err, ShouldBeNil)
+		// 0..4 are read twice and move to the frequent list
+		for i := 0; i < 5; i++ {
+			So(gcache.Set(i, &TestEntry{"arc", i}), ShouldBeTrue)
+			_, ok := gcache.Get(i)
+			So(ok, ShouldBeTrue)
+		}
+		// a scan of one-shot keys must not flush the frequent ones
+		for i := 100; i < 120; i++ {
+			So(gcache.Set(i, &TestEntry{"arc", i}), ShouldBeTrue)
+		}
+		So(gcache.Count(), ShouldEqual, 10)
+		for i := 0; i < 5; i++ {
+			So(gcache.Contains(i), ShouldBeTrue)
+		}
+		So(gcache.Contains(100), ShouldBeFalse)
+		So(gcache.Close(context.Background()), ShouldBeNil)
+	})
+}
+
+func Test_ARCGhosts(t *testing.T) {
+	noop := func(key, value int, reason cache.RemoveReason) {}
+
+	Convey("a key evicted from the frequent list goes back to it", t, func() {
+		c := ARC.NewBuilder[int, int]().Build(4, 0, noop)
+		for i := 0; i < 4; i++ {
+			c.Add(i, i)
+			c.Get(i)
+		}
+		c.Add(100, 100)
+		So(c.Contains(0), ShouldBeFalse)
+		c.Add(0, 0)
+		So(c.Keys(), ShouldResemble, []int{1, 2, 3, 0})
+	})
+
+	Convey("a hit in the frequent ghosts lowers the target of the recent list", t, func() {
+		c := ARC.NewBuilder[int, int]().Build(4, 0, noop)
+		for i := 0; i < 2; i++ {
+			c.Add(i, i)
+			c.Get(i)
+		}
+		c.Add(2, 2)
+		c.Add(3, 3)
+		c.Add(4, 4)
+		// the recent ghost hit raises the target to 1, evicting 3.
+		c.Add(2, 2)
+		So(c.Keys(), ShouldResemble, []int{4, 0, 1, 2})
+		// with a target of 1 the frequent list gives up 0.
+		c.Add(5, 5)
+		So(c.Keys(), ShouldResemble, []int{4, 5, 1, 2})
+		// the frequent ghost hit lowers the target to 0, the next miss evicts from the recent list.
+		c.Add(0, 0)
+		So(c.Keys(), ShouldResemble, []int{5, 1, 2, 0})
+		c.Add(6, 6)
+		So(c.Keys(), ShouldResemble, []int{6, 1, 2, 0})
+	})
+}
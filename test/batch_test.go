@@ -0,0 +1,45 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func Test_Batch(t *testing.T) {
+	Convey("batch test", t, func() {
+		c, err := gcache.New[int, string]("test_batch", gcache.WithShards(8), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		entries := make(map[int]string)
+		for i := 0; i < 100; i++ {
+			entries[i] = fmt.Sprint(i)
+		}
+		c.SetMany(entries)
+		So(c.Count(), ShouldEqual, 100)
+
+		values := c.GetMany([]int{1, 50, 99, 100})
+		So(values, ShouldResemble, map[int]string{1: "1", 50: "50", 99: "99"})
+
+		So(c.DeleteMany([]int{1, 2, 3, 1000}), ShouldEqual, 3)
+		So(c.Count(), ShouldEqual, 97)
+
+		var loadedKeys []int
+		values, err = c.GetOrLoadMany(context.Background(), []int{1, 4, 200}, func(ctx context.Context, keys []int) (map[int]string, error) {
+			loadedKeys = keys
+			loaded := make(map[int]string)
+			for _, key := range keys {
+				loaded[key] = fmt.Sprint("loaded ", key)
+			}
+			return loaded, nil
+		})
+		So(err, ShouldBeNil)
+		So(loadedKeys, ShouldHaveLength, 2)
+		So(values, ShouldResemble, map[int]string{1: "loaded 1", 4: "4", 200: "loaded 200"})
+		v, ok := c.Get(200)
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, "loaded 200")
+	})
+}
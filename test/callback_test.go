@@ -0,0 +1,75 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_RemoveCallback(t *testing.T) {
+	Convey("removal callbacks are delivered in order after the lock is released", t, func() {
+		var (
+			mu      sync.Mutex
+			evicted []interface{}
+			c       *gcache.GCache
+		)
+		c, err := gcache.NewGCache("test_callback",
+			gcache.WithShards(1),
+			gcache.WithMaxEntrySize(10),
+			gcache.WithCallbackQueueSize(4),
+			gcache.WithCallbackOverflowPolicy(gcache.OverflowBlock),
+			gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+				// touching the cache from the callback must not deadlock
+				c.Contains(key)
+				mu.Lock()
+				defer mu.Unlock()
+				if reason == cache.NoSpace {
+					evicted = append(evicted, key)
+				}
+			}))
+		So(err, ShouldBeNil)
+		for i := 0; i < 30; i++ {
+			c.Set(i, i)
+		}
+		So(c.Count(), ShouldEqual, 10)
+		So(c.Close(context.Background()), ShouldBeNil)
+
+		mu.Lock()
+		defer mu.Unlock()
+		So(len(evicted), ShouldEqual, 20)
+		for i := range evicted {
+			So(evicted[i], ShouldEqual, i)
+		}
+	})
+
+	Convey("overflowing notifications can be dropped", t, func() {
+		block := make(chan struct{})
+		c, err := gcache.NewGCache("test_callback_drop",
+			gcache.WithShards(1),
+			gcache.WithMaxEntrySize(10),
+			gcache.WithCallbackQueueSize(1),
+			gcache.WithCallbackOverflowPolicy(gcache.OverflowDrop),
+			gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+				<-block
+			}))
+		So(err, ShouldBeNil)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 30; i++ {
+				c.Set(i, i)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("writers blocked on a full callback queue")
+		}
+		close(block)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache"
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Close(t *testing.T) {
+	Convey("close test", t, func() {
+		var cleared int32
+		c, err := gcache.New[int, int]("test_close",
+			gcache.WithShards(4),
+			gcache.WithClearOnClose(true),
+			gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+				if reason == cache.Clear {
+					atomic.AddInt32(&cleared, 1)
+				}
+			}))
+		So(err, ShouldBeNil)
+		for i := 0; i < 10; i++ {
+			c.Set(i, i)
+		}
+		loaded := make(chan struct{})
+		go c.GetOrLoad(context.Background(), 100, func(ctx context.Context, key int) (int, error) {
+			close(loaded)
+			time.Sleep(50 * time.Millisecond)
+			return key, nil
+		})
+		<-loaded
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		So(errors.Is(c.Close(ctx), context.DeadlineExceeded), ShouldBeTrue)
+		// a later Close waits for the shutdown in progress.
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		So(errors.Is(c.Close(ctx), context.DeadlineExceeded), ShouldBeTrue)
+		So(c.Close(context.Background()), ShouldBeNil)
+		So(atomic.LoadInt32(&cleared), ShouldEqual, 11)
+		So(c.Close(context.Background()), ShouldBeNil)
+
+		So(c.Set(1, 1), ShouldBeFalse)
+		_, ok := c.Get(1)
+		So(ok, ShouldBeFalse)
+		_, err = c.GetOrLoad(context.Background(), 1, func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		})
+		So(err, ShouldEqual, gcache.ErrClosed)
+		_, err = c.IncrBy(1, 1)
+		So(err, ShouldEqual, gcache.ErrClosed)
+		So(c.Count(), ShouldEqual, 0)
+		So(c.Stats().Sets, ShouldEqual, 0)
+	})
+
+	Convey("close waits for the operations in flight", t, func() {
+		var (
+			removed  int32
+			finished atomic.Bool
+		)
+		c, err := gcache.New[int, int]("test_close_ops",
+			gcache.WithShards(1),
+			gcache.WithClearOnClose(true),
+			gcache.WithCallbackQueueSize(1),
+			gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+				atomic.AddInt32(&removed, 1)
+			}))
+		So(err, ShouldBeNil)
+		c.Set(1, 1)
+		walking := make(chan struct{})
+		closed := make(chan error)
+		go c.Range(func(key, value int) bool {
+			close(walking)
+			time.Sleep(20 * time.Millisecond)
+			finished.Store(true)
+			return true
+		})
+		<-walking
+		go func() {
+			closed <- c.Close(context.Background())
+		}()
+		So(<-closed, ShouldBeNil)
+		So(finished.Load(), ShouldBeTrue)
+		So(atomic.LoadInt32(&removed), ShouldEqual, 1)
+	})
+
+	Convey("close waits for the clean up in flight", t, func() {
+		var removed int32
+		c, err := gcache.New[int, int]("test_close_clean",
+			gcache.WithShards(4),
+			gcache.WithClearOnClose(true),
+			gcache.WithCleanInterval(time.Millisecond),
+			gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+				atomic.AddInt32(&removed, 1)
+			}))
+		So(err, ShouldBeNil)
+		for i := 0; i < 1000; i++ {
+			c.SetWithTTL(i, i, time.Duration(1+i%5)*time.Millisecond)
+		}
+		time.Sleep(3 * time.Millisecond)
+		So(c.Close(context.Background()), ShouldBeNil)
+		// every entry was delivered, expired or cleared, before Close returned
+		So(atomic.LoadInt32(&removed), ShouldEqual, 1000)
+	})
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+)
+
+func Test_Compute(t *testing.T) {
+	Convey("compute test", t, func() {
+		c, err := gcache.New[string, int]("test_compute", gcache.WithShards(4), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					c.Compute("counter", func(old int, present bool) (int, gcache.ComputeAction) {
+						return old + 1, gcache.ComputeReplace
+					})
+				}
+			}()
+		}
+		wg.Wait()
+		v, ok := c.Get("counter")
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, 5000)
+
+		v, ok = c.Compute("counter", func(old int, present bool) (int, gcache.ComputeAction) {
+			return 0, gcache.ComputeKeep
+		})
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, 5000)
+		_, ok = c.Compute("counter", func(old int, present bool) (int, gcache.ComputeAction) {
+			return 0, gcache.ComputeDelete
+		})
+		So(ok, ShouldBeFalse)
+		So(c.Contains("counter"), ShouldBeFalse)
+
+		So(c.ComputeIfAbsent("a", func(key string) int { return 1 }), ShouldEqual, 1)
+		So(c.ComputeIfAbsent("a", func(key string) int { return 2 }), ShouldEqual, 1)
+		v, ok = c.ComputeIfPresent("a", func(key string, old int) int { return old + 10 })
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, 11)
+		_, ok = c.ComputeIfPresent("b", func(key string, old int) int { return old + 10 })
+		So(ok, ShouldBeFalse)
+		So(c.Contains("b"), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+)
+
+func Test_Cost(t *testing.T) {
+	Convey("cost test", t, func() {
+		c, err := gcache.New[int, string]("test_cost",
+			gcache.WithShards(1),
+			gcache.WithMaxCost(100),
+			gcache.WithCostFunc(func(key, value interface{}) int64 {
+				return int64(len(value.(string)))
+			}),
+			gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		for i := 0; i < 10; i++ {
+			c.Set(i, "0123456789")
+		}
+		So(c.Count(), ShouldEqual, 10)
+		c.Set(10, "0123456789")
+		So(c.Count(), ShouldEqual, 10)
+		So(c.Contains(0), ShouldBeFalse)
+
+		c.SetWithCost(11, "big", 50)
+		So(c.Count(), ShouldEqual, 6)
+		So(c.Contains(11), ShouldBeTrue)
+
+		So(c.Delete(11), ShouldBeTrue)
+		for i := 20; i < 25; i++ {
+			c.Set(i, "0123456789")
+		}
+		So(c.Count(), ShouldEqual, 10)
+
+		So(c.SetWithCost(30, "huge", 101), ShouldBeFalse)
+		So(c.SetE(31, strings.Repeat("0", 101)), ShouldEqual, gcache.ErrCapacity)
+		So(c.SetWithCost(20, "huge", 101), ShouldBeFalse)
+		So(c.Count(), ShouldEqual, 10)
+		v, ok := c.Get(20)
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, "0123456789")
+	})
+
+	Convey("estimate size test", t, func() {
+		So(gcache.EstimateSize("abcd"), ShouldBeGreaterThan, gcache.EstimateSize(""))
+		So(gcache.EstimateSize([]int64{1, 2, 3}), ShouldBeGreaterThanOrEqualTo, 24)
+	})
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_Counter(t *testing.T) {
+	Convey("counter test", t, func() {
+		c, err := gcache.NewGCache("test_counter", gcache.WithShards(4), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+
+		var (
+			wg     sync.WaitGroup
+			failed int32
+		)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if _, err := c.IncrBy("hits", 2); err != nil {
+						atomic.AddInt32(&failed, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		So(failed, ShouldEqual, 0)
+		n, err := c.DecrBy("hits", 1)
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, 3999)
+		v, _ := c.Get("hits")
+		So(v, ShouldEqual, int64(3999))
+
+		c.Set("small", int8(100))
+		_, err = c.IncrBy("small", 100)
+		So(err, ShouldNotBeNil)
+		n, err = c.IncrBy("small", 27)
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, math.MaxInt8)
+
+		f, err := c.IncrByFloat("ratio", 0.5)
+		So(err, ShouldBeNil)
+		So(f, ShouldEqual, 0.5)
+
+		c.Set("name", "gcache")
+		_, err = c.IncrBy("name", 1)
+		var notNumeric *gcache.NotNumericError
+		So(errors.As(err, &notNumeric), ShouldBeTrue)
+		So(notNumeric.Key, ShouldEqual, "name")
+		_, err = c.IncrByFloat("hits", 1)
+		So(errors.As(err, &notNumeric), ShouldBeTrue)
+	})
+
+	Convey("typed counter test", t, func() {
+		c, err := gcache.New[string, uint32]("test_typed_counter", gcache.WithShards(1), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		n, err := c.IncrBy("k", 5)
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, 5)
+		_, err = c.DecrBy("k", 6)
+		So(err, ShouldNotBeNil)
+		v, _ := c.Get("k")
+		So(v, ShouldEqual, 5)
+	})
+}
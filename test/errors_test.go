@@ -0,0 +1,83 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Errors(t *testing.T) {
+	Convey("errors test", t, func() {
+		c, err := gcache.New[string, int]("test_errors",
+			gcache.WithShards(1),
+			gcache.WithMaxCost(100),
+			gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+
+		So(c.SetE("a", 1), ShouldBeNil)
+		v, err := c.GetE("a")
+		So(err, ShouldBeNil)
+		So(v, ShouldEqual, 1)
+		_, err = c.GetE("b")
+		So(err, ShouldEqual, gcache.ErrNotFound)
+		So(c.SetWithTTLE("short", 2, time.Millisecond), ShouldBeNil)
+		time.Sleep(5 * time.Millisecond)
+		_, err = c.GetE("short")
+		So(err, ShouldEqual, gcache.ErrExpired)
+		_, err = c.GetE("short")
+		So(err, ShouldEqual, gcache.ErrNotFound)
+
+		So(c.SetWithCost("big", 3, 1000), ShouldBeFalse)
+		So(c.Contains("big"), ShouldBeFalse)
+		So(c.Contains("a"), ShouldBeTrue)
+		So(c.DeleteE("big"), ShouldEqual, gcache.ErrNotFound)
+
+		cause := errors.New("backend down")
+		_, err = c.GetOrLoad(context.Background(), "load", func(ctx context.Context, key string) (int, error) {
+			return 0, cause
+		})
+		So(errors.Is(err, gcache.ErrLoaderFailed), ShouldBeTrue)
+		So(errors.Is(err, cause), ShouldBeTrue)
+
+		So(c.Close(context.Background()), ShouldBeNil)
+		_, err = c.GetE("a")
+		So(err, ShouldEqual, gcache.ErrClosed)
+		So(c.SetE("a", 1), ShouldEqual, gcache.ErrClosed)
+	})
+
+	Convey("resetting the stats does not make misses look expired", t, func() {
+		c, err := gcache.New[int, int]("test_errors_reset", gcache.WithShards(1), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		var (
+			wg      sync.WaitGroup
+			expired int32
+			done    atomic.Bool
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20000; i++ {
+				c.SetWithTTL(i, i, time.Nanosecond)
+				c.Get(i)
+				c.ResetStats()
+			}
+			done.Store(true)
+		}()
+		go func() {
+			defer wg.Done()
+			for !done.Load() {
+				if _, err := c.GetE(-1); err == gcache.ErrExpired {
+					atomic.AddInt32(&expired, 1)
+				}
+			}
+		}()
+		wg.Wait()
+		So(atomic.LoadInt32(&expired), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache/LFU"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"strconv"
+	"testing"
+)
+
+type userID int64
+
+func Test_GenericCache(t *testing.T) {
+	Convey("typed cache test", t, func() {
+		c, err := gcache.New[string, *TestEntry]("test_generic")
+		So(err, ShouldBeNil)
+		So(c.Set("aaa", &TestEntry{"1", 1}), ShouldBeTrue)
+		v, ok := c.Get("aaa")
+		So(ok, ShouldBeTrue)
+		So(v.id, ShouldEqual, 1)
+		v, ok = c.Get("bbb")
+		So(ok, ShouldBeFalse)
+		So(v, ShouldBeNil)
+
+		v, err = c.GetOrLoad(context.Background(), "ccc", func(ctx context.Context, key string) (*TestEntry, error) {
+			return &TestEntry{key, 3}, nil
+		})
+		So(err, ShouldBeNil)
+		So(v.name, ShouldEqual, "ccc")
+		So(c.Count(), ShouldEqual, 2)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+
+	Convey("typed cache with a named key type and strategy", t, func() {
+		c, err := gcache.New[userID, string]("test_generic_lfu",
+			gcache.WithShards(4),
+			gcache.WithEvictStrategy(LFU.Name),
+			gcache.WithKeyHasher[userID](hasherFunc(func(id userID) uint64 { return uint64(id) })))
+		So(err, ShouldBeNil)
+		for i := userID(0); i < 100; i++ {
+			So(c.Set(i, strconv.Itoa(int(i))), ShouldBeTrue)
+		}
+		name, ok := c.Get(42)
+		So(ok, ShouldBeTrue)
+		So(name, ShouldEqual, "42")
+		So(c.Count(), ShouldEqual, 100)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+}
+
+type hasherFunc func(userID) uint64
+
+func (f hasherFunc) Sum64(id userID) uint64 {
+	return f(id)
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	"strconv"
+	"testing"
+)
+
+const benchShards = 1024
+
+var benchHashers = []struct {
+	name   string
+	hasher gcache.Hasher[interface{}]
+}{
+	{"fnv", gcache.NewFNVHasher()},
+	{"default", gcache.NewDefaultHasher()},
+}
+
+// shardSpread returns the largest number of keys landing in one shard over the mean number per shard,
+// 1 for a perfect spread.
+func shardSpread(h gcache.Hasher[interface{}], keys []interface{}) float64 {
+	var counts [benchShards]int
+	for _, key := range keys {
+		counts[h.Sum64(key)&(benchShards-1)]++
+	}
+	largest := 0
+	for _, n := range counts {
+		largest = max(largest, n)
+	}
+	return float64(largest) * benchShards / float64(len(keys))
+}
+
+func benchKeys() map[string][]interface{} {
+	keys := map[string][]interface{}{}
+	for i := 0; i < 100000; i++ {
+		keys["sequential"] = append(keys["sequential"], int64(i))
+		keys["strided"] = append(keys["strided"], int64(i*benchShards))
+		keys["string"] = append(keys["string"], "user:"+strconv.Itoa(i))
+	}
+	return keys
+}
+
+// BenchmarkHasher reports the ns/op of Sum64 and the spread of the keys over 1024 shards.
+func BenchmarkHasher(b *testing.B) {
+	for kind, keys := range benchKeys() {
+		for _, h := range benchHashers {
+			b.Run(kind+"/"+h.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					h.hasher.Sum64(keys[i%len(keys)])
+				}
+				b.ReportMetric(shardSpread(h.hasher, keys), "max/mean")
+			})
+		}
+	}
+}
+
+// BenchmarkSetGet measures a typed cache with each hasher, which is specialized for the key type.
+func BenchmarkSetGet(b *testing.B) {
+	for _, h := range benchHashers {
+		b.Run("int/"+h.name, func(b *testing.B) {
+			c, err := gcache.New[int, int]("bench_hasher", gcache.WithHasher(h.hasher), gcache.WithCleanInterval(0), gcache.WithDevelopment(false))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer c.Close(context.Background())
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Set(i*benchShards, i)
+					c.Get(i * benchShards)
+					i++
+				}
+			})
+		})
+		b.Run("string/"+h.name, func(b *testing.B) {
+			c, err := gcache.New[string, int]("bench_hasher", gcache.WithHasher(h.hasher), gcache.WithCleanInterval(0), gcache.WithDevelopment(false))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer c.Close(context.Background())
+			keys := make([]string, 4096)
+			for i := range keys {
+				keys[i] = "user:" + strconv.Itoa(i)
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Set(keys[i%len(keys)], i)
+					c.Get(keys[i%len(keys)])
+					i++
+				}
+			})
+		})
+	}
+}
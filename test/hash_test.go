@@ -0,0 +1,113 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+type pointKey struct {
+	x, y int
+}
+
+type hashedKey int
+
+func (k hashedKey) Hash64() uint64 {
+	return uint64(k) * 3
+}
+
+// usedShards returns the number of shards the sets of the cache went to.
+func usedShards(stats gcache.Stats) int {
+	used := 0
+	for _, s := range stats.Shards {
+		if s.Sets > 0 {
+			used++
+		}
+	}
+	return used
+}
+
+func Test_Hasher(t *testing.T) {
+	Convey("keys of any comparable type are spread over the shards", t, func() {
+		c, err := gcache.NewGCache("test_hash_any", gcache.WithShards(16), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		for i := 0; i < 200; i++ {
+			c.Set(pointKey{i, -i}, i)
+			c.Set([2]int{i, i}, i)
+			c.Set(hashedKey(i), i)
+		}
+		c.Set(true, 1)
+		c.Set(complex(1, 2), 1)
+		So(usedShards(c.Stats()), ShouldEqual, 16)
+		v, ok := c.Get(pointKey{7, -7})
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, 7)
+		So(c.Contains([2]int{9, 9}), ShouldBeTrue)
+		So(c.Contains(true), ShouldBeTrue)
+		So(c.Contains(complex(1, 2)), ShouldBeTrue)
+	})
+
+	Convey("typed caches of struct keys are spread over the shards", t, func() {
+		c, err := gcache.New[pointKey, int]("test_hash_struct", gcache.WithShards(16), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		for i := 0; i < 200; i++ {
+			c.Set(pointKey{i, i}, i)
+		}
+		So(usedShards(c.Stats()), ShouldEqual, 16)
+		v, ok := c.Get(pointKey{3, 3})
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, 3)
+	})
+}
+
+type anyKey struct {
+	v interface{}
+}
+
+func Test_UnhashableKeys(t *testing.T) {
+	Convey("keys which can not be map keys are rejected", t, func() {
+		c, err := gcache.NewGCache("test_hash_unhashable", gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		So(c.Set([]byte("x"), 1), ShouldBeFalse)
+		So(c.SetE(map[string]int{}, 1), ShouldEqual, gcache.ErrUnhashableKey)
+		_, err = c.GetE([]byte("x"))
+		So(err, ShouldEqual, gcache.ErrUnhashableKey)
+		So(c.Contains([]byte("x")), ShouldBeFalse)
+		So(c.Set(nil, 1), ShouldBeTrue)
+		So(c.Contains(nil), ShouldBeTrue)
+		So(c.GetMany([]interface{}{[]byte("x"), nil}), ShouldResemble, map[interface{}]interface{}{nil: 1})
+
+		typed, err := gcache.New[anyKey, int]("test_hash_unhashable_struct", gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer typed.Close(context.Background())
+		So(typed.Set(anyKey{[]int{1}}, 1), ShouldBeFalse)
+		So(typed.Set(anyKey{1}, 1), ShouldBeTrue)
+		So(typed.Contains(anyKey{1}), ShouldBeTrue)
+	})
+
+	Convey("equal floats hash the same", t, func() {
+		negativeZero := math.Copysign(0, -1)
+		for _, h := range []gcache.Hasher[interface{}]{gcache.NewFNVHasher(), gcache.NewDefaultHasher()} {
+			So(h.Sum64(complex(negativeZero, 0)), ShouldEqual, h.Sum64(complex(0, 0)))
+			So(h.Sum64(complex(0, negativeZero)), ShouldEqual, h.Sum64(complex(0, 0)))
+			So(h.Sum64(negativeZero), ShouldEqual, h.Sum64(0.0))
+		}
+	})
+}
+
+func Test_DefaultHasherMixesIntegers(t *testing.T) {
+	Convey("strided integer keys are spread over the shards", t, func() {
+		c, err := gcache.New[int64, int]("test_hash_strided", gcache.WithShards(16), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		for i := int64(0); i < 200; i++ {
+			c.Set(i*1024, 0)
+		}
+		So(usedShards(c.Stats()), ShouldEqual, 16)
+	})
+}
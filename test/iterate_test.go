@@ -0,0 +1,49 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func Test_Iterate(t *testing.T) {
+	Convey("iterate test", t, func() {
+		c, err := gcache.New[int, int]("test_iterate", gcache.WithShards(4), gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		for i := 0; i < 100; i++ {
+			c.Set(i, i*i)
+		}
+		c.SetWithTTL(1000, 0, time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+
+		seen := make(map[int]int)
+		for k, v := range c.All() {
+			seen[k] = v
+			// the cache can be used while iterating
+			c.Delete(k)
+		}
+		So(len(seen), ShouldEqual, 100)
+		So(seen[9], ShouldEqual, 81)
+		So(c.Count(), ShouldEqual, 0)
+
+		for i := 0; i < 10; i++ {
+			c.Set(i, i)
+		}
+		n := 0
+		for range c.Keys() {
+			if n++; n == 3 {
+				break
+			}
+		}
+		So(n, ShouldEqual, 3)
+		n = 0
+		c.Range(func(key, value int) bool {
+			n++
+			return true
+		})
+		So(n, ShouldEqual, 10)
+	})
+}
@@ -0,0 +1,36 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache/LFU"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func Test_LFUCache(t *testing.T) {
+	Convey("new LFU gCache test", t, func() {
+		gcache, err := gcache.NewGCache("test_lfu",
+			gcache.WithShards(1),
+			gcache.WithMaxEntrySize(10),
+			gcache.WithEvictStrategy(LFU.Name))
+		So(err, ShouldBeNil)
+		for i := 0; i < 10; i++ {
+			So(gcache.Set(i, &TestEntry{"lfu", i}), ShouldBeTrue)
+		}
+		// every key but 0 becomes hot, so 0 is the one to be evicted
+		for i := 1; i < 10; i++ {
+			_, ok := gcache.Get(i)
+			So(ok, ShouldBeTrue)
+		}
+		So(gcache.Set(10, &TestEntry{"lfu", 10}), ShouldBeTrue)
+		So(gcache.Count(), ShouldEqual, 10)
+		So(gcache.Contains(0), ShouldBeFalse)
+		So(gcache.Contains(10), ShouldBeTrue)
+
+		ok := gcache.Delete(5)
+		So(ok, ShouldBeTrue)
+		So(gcache.Count(), ShouldEqual, 9)
+		So(gcache.Close(context.Background()), ShouldBeNil)
+	})
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_GetOrLoad(t *testing.T) {
+	Convey("concurrent misses share one load", t, func() {
+		var calls int32
+		c, err := gcache.NewGCache("test_loader", gcache.WithLoader(func(ctx context.Context, key interface{}) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(50 * time.Millisecond)
+			if key == "bad" {
+				return nil, errors.New("load failed")
+			}
+			return &TestEntry{key.(string), 1}, nil
+		}))
+		So(err, ShouldBeNil)
+
+		var wg sync.WaitGroup
+		values := make([]interface{}, 50)
+		errs := make([]error, 50)
+		for i := range values {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				values[i], errs[i] = c.GetOrLoad(context.Background(), "hot", nil)
+			}(i)
+		}
+		wg.Wait()
+		So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+		for i := range values {
+			So(errs[i], ShouldBeNil)
+			So(values[i], ShouldEqual, values[0])
+		}
+		v, ok := c.Get("hot")
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, values[0])
+
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = c.GetOrLoad(context.Background(), "bad", nil)
+			}(i)
+		}
+		wg.Wait()
+		for i := range errs {
+			So(errs[i], ShouldNotBeNil)
+		}
+		So(c.Contains("bad"), ShouldBeFalse)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+
+	Convey("a cache without loader", t, func() {
+		c, err := gcache.New[string, int]("test_no_loader", gcache.WithShards(1), gcache.WithMaxEntrySize(10))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		_, err = c.GetOrLoad(context.Background(), "k", nil)
+		So(errors.Is(err, gcache.ErrNoLoader), ShouldBeTrue)
+		_, err = c.GetOrLoadMany(context.Background(), []string{"k"}, nil)
+		So(errors.Is(err, gcache.ErrNoLoader), ShouldBeTrue)
+	})
+}
+
+func Test_RefreshAfterWrite(t *testing.T) {
+	Convey("stale entries are reloaded in the background", t, func() {
+		var (
+			calls int32
+			fail  atomic.Bool
+		)
+		c, err := gcache.New[string, int32]("test_refresh",
+			gcache.WithShards(1),
+			gcache.WithCleanInterval(0),
+			gcache.WithRefreshAfterWrite(20*time.Millisecond),
+			gcache.WithLoader(func(ctx context.Context, key interface{}) (interface{}, error) {
+				if fail.Load() {
+					return nil, errors.New("unavailable")
+				}
+				return atomic.AddInt32(&calls, 1), nil
+			}))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		v, err := c.GetOrLoad(context.Background(), "k", nil)
+		So(err, ShouldBeNil)
+		So(v, ShouldEqual, 1)
+
+		time.Sleep(30 * time.Millisecond)
+		for i := 0; i < 10; i++ {
+			v, _ = c.Get("k")
+			So(v, ShouldEqual, 1)
+		}
+		time.Sleep(10 * time.Millisecond)
+		So(atomic.LoadInt32(&calls), ShouldEqual, 2)
+		v, _ = c.Get("k")
+		So(v, ShouldEqual, 2)
+
+		fail.Store(true)
+		time.Sleep(30 * time.Millisecond)
+		v, _ = c.Get("k")
+		So(v, ShouldEqual, 2)
+		time.Sleep(10 * time.Millisecond)
+		v, ok := c.Get("k")
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, 2)
+	})
+
+	Convey("reloaded entries keep their ttl", t, func() {
+		var calls int32
+		c, err := gcache.New[string, int32]("test_refresh_ttl",
+			gcache.WithShards(1),
+			gcache.WithMaxEntrySize(10),
+			gcache.WithCleanInterval(0),
+			gcache.WithExpiration(100*time.Millisecond),
+			gcache.WithRefreshAfterWrite(20*time.Millisecond),
+			gcache.WithLoader(func(ctx context.Context, key interface{}) (interface{}, error) {
+				return atomic.AddInt32(&calls, 1), nil
+			}))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		c.SetWithTTL("k", 0, gcache.NoExpiration)
+
+		time.Sleep(30 * time.Millisecond)
+		c.Get("k")
+		time.Sleep(10 * time.Millisecond)
+		v, _ := c.Get("k")
+		So(v, ShouldEqual, 1)
+		// the reload did not bring in the Expiration of the cache
+		time.Sleep(120 * time.Millisecond)
+		_, ok := c.Get("k")
+		So(ok, ShouldBeTrue)
+	})
+}
+
+func Test_SharedLoadContext(t *testing.T) {
+	Convey("a caller giving up does not cancel the load of the others", t, func() {
+		started := make(chan struct{})
+		c, err := gcache.New[string, int]("test_load_ctx", gcache.WithLoadTimeout(30*time.Millisecond))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		loader := func(ctx context.Context, key string) (int, error) {
+			close(started)
+			select {
+			case <-time.After(20 * time.Millisecond):
+				return 1, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error)
+		go func() {
+			_, err := c.GetOrLoad(ctx, "k", loader)
+			first <- err
+		}()
+		<-started
+		cancel()
+		So(<-first, ShouldEqual, context.Canceled)
+		v, err := c.GetOrLoad(context.Background(), "k", loader)
+		So(err, ShouldBeNil)
+		So(v, ShouldEqual, 1)
+
+		_, err = c.GetOrLoad(context.Background(), "slow", func(ctx context.Context, key string) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		So(errors.Is(err, gcache.ErrLoaderFailed), ShouldBeTrue)
+		So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+	})
+}
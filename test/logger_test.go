@@ -0,0 +1,93 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bytes"
+	"context"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *recordLogger) record(level, format string, v ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, level+" "+fmt.Sprintf(format, v...))
+}
+
+func (r *recordLogger) Debugf(format string, v ...interface{}) { r.record("debug", format, v...) }
+func (r *recordLogger) Infof(format string, v ...interface{})  { r.record("info", format, v...) }
+func (r *recordLogger) Warnf(format string, v ...interface{})  { r.record("warn", format, v...) }
+func (r *recordLogger) Errorf(format string, v ...interface{}) { r.record("error", format, v...) }
+func (r *recordLogger) Fatalf(format string, v ...interface{}) { r.record("fatal", format, v...) }
+
+func (r *recordLogger) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.lines)
+}
+
+func Test_Logger(t *testing.T) {
+	Convey("each cache logs to its own logger", t, func() {
+		first, second := &recordLogger{}, &recordLogger{}
+		c1, err := gcache.New[string, int]("test_logger_first", gcache.WithLogger(first))
+		So(err, ShouldBeNil)
+		defer c1.Close(context.Background())
+		c2, err := gcache.New[string, int]("test_logger_second", gcache.WithLogger(second))
+		So(err, ShouldBeNil)
+		defer c2.Close(context.Background())
+
+		c1.Set("k", 1)
+		c1.Delete("k")
+		So(first.count(), ShouldEqual, 1)
+		So(first.lines[0], ShouldStartWith, "debug cache test_logger_first: key k is evicted")
+		So(second.count(), ShouldEqual, 0)
+	})
+
+	Convey("evictions are not logged out of development mode", t, func() {
+		logger := &recordLogger{}
+		c, err := gcache.New[string, int]("test_logger_production", gcache.WithLogger(logger), gcache.WithDevelopment(false))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		c.Set("k", 1)
+		c.Delete("k")
+		So(logger.count(), ShouldEqual, 0)
+	})
+
+	Convey("messages below the level are dropped", t, func() {
+		logger := &recordLogger{}
+		c, err := gcache.New[string, int]("test_logger_level", gcache.WithLogger(logger), gcache.WithLogLevel(gcache.LogInfo))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		c.Set("k", 1)
+		c.Delete("k")
+		So(logger.count(), ShouldEqual, 0)
+	})
+
+	Convey("slog adapter", t, func() {
+		var buf bytes.Buffer
+		logger := gcache.NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		c, err := gcache.New[string, int]("test_logger_slog", gcache.WithLogger(logger))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		c.Set("k", 1)
+		c.Delete("k")
+		So(buf.String(), ShouldContainSubstring, "level=DEBUG")
+		So(buf.String(), ShouldContainSubstring, "cache test_logger_slog: key k is evicted")
+
+		buf.Reset()
+		logger = gcache.NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+		logger.Debugf("hidden")
+		logger.Warnf("warn %d", 1)
+		So(strings.TrimSpace(buf.String()), ShouldEndWith, `level=WARN msg="warn 1"`)
+		So(buf.String(), ShouldNotContainSubstring, "hidden")
+	})
+}
@@ -0,0 +1,37 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_MetricsHandler(t *testing.T) {
+	Convey("prometheus metrics test", t, func() {
+		c, err := gcache.NewGCache("test_metrics")
+		So(err, ShouldBeNil)
+		c.Set("aaa", 1)
+		c.Get("aaa")
+		c.Get("bbb")
+		c.Delete("aaa")
+
+		rec := httptest.NewRecorder()
+		gcache.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		So(rec.Header().Get("Content-Type"), ShouldStartWith, "text/plain; version=0.0.4")
+		body, _ := io.ReadAll(rec.Body)
+		So(string(body), ShouldContainSubstring, "# TYPE gcache_hits_total counter\n")
+		So(string(body), ShouldContainSubstring, `gcache_hits_total{cache="test_metrics"} 1`+"\n")
+		So(string(body), ShouldContainSubstring, `gcache_misses_total{cache="test_metrics"} 1`+"\n")
+		So(string(body), ShouldContainSubstring, `gcache_evictions_total{cache="test_metrics",reason="Deleted"} 1`+"\n")
+		So(string(body), ShouldContainSubstring, `gcache_entries{cache="test_metrics"} 0`+"\n")
+
+		So(c.Close(context.Background()), ShouldBeNil)
+		rec = httptest.NewRecorder()
+		gcache.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		body, _ = io.ReadAll(rec.Body)
+		So(string(body), ShouldNotContainSubstring, `cache="test_metrics"`)
+	})
+}
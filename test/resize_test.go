@@ -0,0 +1,71 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func Test_PurgeResize(t *testing.T) {
+	Convey("purge and resize test", t, func() {
+		for _, strategy := range []string{"LRU", "LFU", "ARC", "TinyLFU"} {
+			reasons := make(chan cache.RemoveReason, 200)
+			c, err := gcache.New[int, int]("test_resize",
+				gcache.WithShards(1),
+				gcache.WithMaxEntrySize(100),
+				gcache.WithEvictStrategy(strategy),
+				gcache.WithCleanInterval(0),
+				gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+					reasons <- reason
+				}))
+			So(err, ShouldBeNil)
+			for i := 0; i < 100; i++ {
+				c.Set(i, i)
+			}
+			So(c.Count(), ShouldEqual, 100)
+
+			So(c.Resize(40), ShouldEqual, 60)
+			So(c.Count(), ShouldEqual, 40)
+			for i := 100; i < 200; i++ {
+				c.Set(i, i)
+			}
+			So(c.Count(), ShouldBeLessThanOrEqualTo, 40)
+
+			c.Purge()
+			So(c.Count(), ShouldEqual, 0)
+			c.Set(1, 1)
+			So(c.Contains(1), ShouldBeTrue)
+			So(c.Close(context.Background()), ShouldBeNil)
+
+			counts := make(map[cache.RemoveReason]int)
+			for len(reasons) > 0 {
+				counts[<-reasons]++
+			}
+			So(counts[cache.NoSpace], ShouldBeGreaterThanOrEqualTo, 60)
+			So(counts[cache.Clear], ShouldBeGreaterThan, 0)
+		}
+	})
+
+	Convey("resize splits the capacity over many shards", t, func() {
+		c, err := gcache.New[int, int]("test_resize_shards", gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		for i := 0; i < 5000; i++ {
+			c.Set(i, i)
+		}
+		So(c.Count(), ShouldEqual, 5000)
+		c.Resize(2000)
+		So(c.Count(), ShouldBeLessThanOrEqualTo, 2000)
+		So(c.Resize(100), ShouldBeGreaterThan, 0)
+		So(c.Count(), ShouldBeLessThanOrEqualTo, 100)
+		for i := 0; i < 5000; i++ {
+			c.Set(i, i)
+		}
+		So(c.Count(), ShouldBeLessThanOrEqualTo, 100)
+		c.Resize(0)
+		So(c.Count(), ShouldEqual, 0)
+		So(c.Set(1, 1), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bytes"
+	"context"
+	"encoding/binary"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+type Profile struct {
+	Name  string
+	Level int
+}
+
+func Test_Snapshot(t *testing.T) {
+	Convey("snapshot and warm restart test", t, func() {
+		c, err := gcache.New[string, Profile]("test_snapshot", gcache.WithShards(1), gcache.WithMaxEntrySize(10))
+		So(err, ShouldBeNil)
+		for _, name := range []string{"a", "b", "c"} {
+			c.Set(name, Profile{name, 1})
+		}
+		c.SetWithTTL("forever", Profile{"forever", 2}, gcache.NoExpiration)
+		c.SetWithTTL("short", Profile{"short", 3}, 20*time.Millisecond)
+		// "a" becomes the most recently used
+		c.Get("a")
+
+		var buf bytes.Buffer
+		So(c.SaveTo(&buf), ShouldBeNil)
+		So(c.Close(context.Background()), ShouldBeNil)
+		time.Sleep(30 * time.Millisecond)
+
+		restored, err := gcache.New[string, Profile]("test_snapshot", gcache.WithShards(1), gcache.WithMaxEntrySize(10))
+		So(err, ShouldBeNil)
+		So(restored.LoadFrom(&buf), ShouldBeNil)
+		So(restored.Count(), ShouldEqual, 4)
+		p, ok := restored.Get("forever")
+		So(ok, ShouldBeTrue)
+		So(p, ShouldResemble, Profile{"forever", 2})
+		So(restored.Contains("short"), ShouldBeFalse)
+
+		// filling the cache evicts in the saved LRU order: b and c go before a
+		for i := 0; i < 8; i++ {
+			restored.Set(string(rune('k'+i)), Profile{})
+		}
+		So(restored.Contains("b"), ShouldBeFalse)
+		So(restored.Contains("c"), ShouldBeFalse)
+		So(restored.Contains("a"), ShouldBeTrue)
+
+		So(restored.LoadFrom(bytes.NewReader([]byte("garbage"))), ShouldNotBeNil)
+		// a key length of 1TB is refused before it is allocated
+		corrupt := append([]byte("GCSNAP\x01"), binary.AppendVarint(nil, time.Now().UnixNano())...)
+		corrupt = append(corrupt, 1)
+		corrupt = binary.AppendUvarint(corrupt, 1<<40)
+		So(restored.LoadFrom(bytes.NewReader(corrupt)), ShouldNotBeNil)
+		So(restored.Close(context.Background()), ShouldBeNil)
+	})
+}
+
+func Test_SnapshotExpireAfterAccess(t *testing.T) {
+	restart := func(name string, opts ...gcache.Option) *gcache.Cache[string, int] {
+		c, err := gcache.New[string, int](name, opts...)
+		So(err, ShouldBeNil)
+		c.Set("k", 1)
+		time.Sleep(120 * time.Millisecond)
+		var buf bytes.Buffer
+		So(c.SaveTo(&buf), ShouldBeNil)
+		restored, err := gcache.New[string, int](name, opts...)
+		So(err, ShouldBeNil)
+		So(restored.LoadFrom(&buf), ShouldBeNil)
+		So(c.Close(context.Background()), ShouldBeNil)
+		return restored
+	}
+
+	Convey("restored entries keep their idle time", t, func() {
+		c := restart("test_snapshot_access", gcache.WithShards(1), gcache.WithMaxEntrySize(10),
+			gcache.WithExpiration(200*time.Millisecond),
+			gcache.WithExpirationMode(gcache.ExpireAfterAccess))
+		// the remaining 80ms only set the first deadline, a read pushes it back by the whole 200ms
+		time.Sleep(60 * time.Millisecond)
+		_, ok := c.Get("k")
+		So(ok, ShouldBeTrue)
+		time.Sleep(120 * time.Millisecond)
+		So(c.Contains("k"), ShouldBeTrue)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+
+	Convey("restored entries keep their write deadline", t, func() {
+		c := restart("test_snapshot_access_write", gcache.WithShards(1), gcache.WithMaxEntrySize(10),
+			gcache.WithExpiration(300*time.Millisecond),
+			gcache.WithAccessExpiration(200*time.Millisecond),
+			gcache.WithExpirationMode(gcache.ExpireAfterWrite|gcache.ExpireAfterAccess))
+		// read within the idle time, the entry lives up to its write deadline 180ms after the restart
+		time.Sleep(60 * time.Millisecond)
+		_, ok := c.Get("k")
+		So(ok, ShouldBeTrue)
+		time.Sleep(80 * time.Millisecond)
+		_, ok = c.Get("k")
+		So(ok, ShouldBeTrue)
+		time.Sleep(100 * time.Millisecond)
+		So(c.Contains("k"), ShouldBeFalse)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+}
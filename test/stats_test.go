@@ -0,0 +1,77 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Stats(t *testing.T) {
+	Convey("stats test", t, func() {
+		c, err := gcache.New[int, string]("test_stats",
+			gcache.WithShards(2),
+			gcache.WithMaxEntrySize(20),
+			gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		for i := 0; i < 30; i++ {
+			c.Set(i, "v")
+		}
+		c.SetWithTTL(100, "short", time.Millisecond)
+		_, ok := c.Get(29)
+		So(ok, ShouldBeTrue)
+		_, ok = c.Get(1000)
+		So(ok, ShouldBeFalse)
+		time.Sleep(5 * time.Millisecond)
+		_, ok = c.Get(100)
+		So(ok, ShouldBeFalse)
+		So(c.Delete(29), ShouldBeTrue)
+		_, err = c.GetOrLoad(context.Background(), 200, func(ctx context.Context, key int) (string, error) {
+			return "loaded", nil
+		})
+		So(err, ShouldBeNil)
+
+		stats := c.Stats()
+		So(stats.Hits, ShouldEqual, 1)
+		So(stats.Misses, ShouldEqual, 3)
+		So(stats.ExpiredOnRead, ShouldEqual, 1)
+		So(stats.Sets, ShouldEqual, 32)
+		So(stats.Deletes, ShouldEqual, 1)
+		So(stats.Evictions[cache.Deleted], ShouldEqual, 1)
+		So(stats.Evictions[cache.NoSpace], ShouldBeGreaterThan, 0)
+		So(stats.LoadSuccesses, ShouldEqual, 1)
+		So(stats.Entries, ShouldEqual, c.Count())
+		So(len(stats.Shards), ShouldEqual, 2)
+		So(stats.Shards[0].Sets+stats.Shards[1].Sets, ShouldEqual, stats.Sets)
+
+		c.ResetStats()
+		stats = c.Stats()
+		So(stats.Hits, ShouldEqual, 0)
+		So(stats.Sets, ShouldEqual, 0)
+		So(stats.Evictions[cache.NoSpace], ShouldEqual, 0)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+
+	Convey("stats do not remove expired entries", t, func() {
+		var removed int32
+		c, err := gcache.New[int, string]("test_stats_expired",
+			gcache.WithShards(1),
+			gcache.WithMaxEntrySize(10),
+			gcache.WithCleanInterval(0),
+			gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+				atomic.AddInt32(&removed, 1)
+			}))
+		So(err, ShouldBeNil)
+		c.Set(1, "v")
+		c.SetWithTTL(2, "short", time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		So(c.Stats().Entries, ShouldEqual, 2)
+		So(c.Stats().Evictions[cache.Expired], ShouldEqual, 0)
+		So(c.Count(), ShouldEqual, 1)
+		So(c.Close(context.Background()), ShouldBeNil)
+		So(atomic.LoadInt32(&removed), ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache/cache"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func Test_TimerWheel(t *testing.T) {
+	Convey("timer wheel expires only due entries", t, func() {
+		wheel := cache.NewTimerWheel[int, string]()
+		now := time.Now()
+		ttls := []time.Duration{time.Second, 2 * time.Minute, 3 * time.Hour, 2 * 24 * time.Hour, 30 * 24 * time.Hour}
+		entries := make([]*cache.Entry[int, string], len(ttls))
+		for i, ttl := range ttls {
+			entries[i] = &cache.Entry[int, string]{Key: i, Timestamp: now.Add(ttl).UnixNano()}
+			wheel.Schedule(entries[i])
+		}
+		forever := &cache.Entry[int, string]{Key: -1}
+		wheel.Schedule(forever)
+
+		var expired []int
+		collect := func(e *cache.Entry[int, string]) { expired = append(expired, e.Key) }
+		for i, ttl := range ttls {
+			wheel.Advance(now.Add(ttl).UnixNano(), collect)
+			So(len(expired), ShouldEqual, i)
+			wheel.Advance(now.Add(ttl+time.Millisecond).UnixNano(), collect)
+			So(expired, ShouldResemble, []int{0, 1, 2, 3, 4}[:i+1])
+		}
+
+		descheduled := &cache.Entry[int, string]{Key: -2, Timestamp: now.Add(time.Minute).UnixNano()}
+		wheel.Schedule(descheduled)
+		wheel.Deschedule(descheduled)
+		wheel.Advance(now.Add(365*24*time.Hour).UnixNano(), collect)
+		So(len(expired), ShouldEqual, len(ttls))
+	})
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache/TinyLFU"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func Test_TinyLFUCache(t *testing.T) {
+	Convey("new TinyLFU gCache test", t, func() {
+		gcache, err := gcache.NewGCache("test_tinylfu",
+			gcache.WithShards(1),
+			gcache.WithMaxEntrySize(100),
+			gcache.WithEvictStrategy(TinyLFU.Name))
+		So(err, ShouldBeNil)
+		for i := 0; i < 50; i++ {
+			So(gcache.Set(i, &TestEntry{"hot", i}), ShouldBeTrue)
+		}
+		// push the last hot key out of the admission window, where reads do not protect it
+		So(gcache.Set(-1, &TestEntry{"cold", -1}), ShouldBeTrue)
+		for n := 0; n < 5; n++ {
+			for i := 0; i < 50; i++ {
+				_, ok := gcache.Get(i)
+				So(ok, ShouldBeTrue)
+			}
+		}
+		// one-hit wonders must not push the hot keys out
+		for i := 1000; i < 2000; i++ {
+			So(gcache.Set(i, &TestEntry{"cold", i}), ShouldBeTrue)
+		}
+		So(gcache.Count(), ShouldEqual, 100)
+		for i := 0; i < 50; i++ {
+			So(gcache.Contains(i), ShouldBeTrue)
+		}
+		So(gcache.Close(context.Background()), ShouldBeNil)
+	})
+}
+
+func Test_TinyLFUKeyHasher(t *testing.T) {
+	Convey("the sketch hashes the keys with the hasher of the cache", t, func() {
+		var calls int
+		c, err := gcache.New[userID, int]("test_tinylfu_hasher",
+			gcache.WithShards(1),
+			gcache.WithEvictStrategy(TinyLFU.Name),
+			gcache.WithKeyHasher[userID](hasherFunc(func(id userID) uint64 {
+				calls++
+				return uint64(id)
+			})))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		c.Get(1)
+		// once for the shard, once for the sketch
+		So(calls, ShouldEqual, 2)
+		So(testing.AllocsPerRun(100, func() {
+			c.Get(2)
+		}), ShouldEqual, 0)
+	})
+}
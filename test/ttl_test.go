@@ -0,0 +1,117 @@
+package test
+
+import (
+	"bitbucket.org/funplus/gcache"
+	"bitbucket.org/funplus/gcache/cache"
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func Test_SetWithTTL(t *testing.T) {
+	Convey("per entry ttl test", t, func() {
+		c, err := gcache.NewGCache("test_ttl",
+			gcache.WithShards(4),
+			gcache.WithExpiration(time.Hour),
+			gcache.WithCleanInterval(20*time.Millisecond))
+		So(err, ShouldBeNil)
+		So(c.SetWithTTL("short", &TestEntry{"short", 1}, 50*time.Millisecond), ShouldBeTrue)
+		So(c.SetWithTTL("forever", &TestEntry{"forever", 2}, gcache.NoExpiration), ShouldBeTrue)
+		So(c.Set("default", &TestEntry{"default", 3}), ShouldBeTrue)
+		_, ok := c.Get("short")
+		So(ok, ShouldBeTrue)
+
+		time.Sleep(100 * time.Millisecond)
+		_, ok = c.Get("short")
+		So(ok, ShouldBeFalse)
+		_, ok = c.Get("forever")
+		So(ok, ShouldBeTrue)
+		_, ok = c.Get("default")
+		So(ok, ShouldBeTrue)
+		So(c.Count(), ShouldEqual, 2)
+
+		v, loaded := c.LoadOrStoreWithTTL("short", &TestEntry{"short", 4}, time.Minute)
+		So(loaded, ShouldBeFalse)
+		So(v, ShouldBeNil)
+		So(c.Contains("short"), ShouldBeTrue)
+		So(c.Close(context.Background()), ShouldBeNil)
+	})
+}
+
+func Test_ExpireAfterAccess(t *testing.T) {
+	Convey("expire after access test", t, func() {
+		c, err := gcache.New[string, int]("test_access",
+			gcache.WithShards(1),
+			gcache.WithExpiration(60*time.Millisecond),
+			gcache.WithExpirationMode(gcache.ExpireAfterAccess),
+			gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		c.Set("read", 1)
+		c.Set("idle", 2)
+		for i := 0; i < 5; i++ {
+			time.Sleep(30 * time.Millisecond)
+			_, ok := c.Get("read")
+			So(ok, ShouldBeTrue)
+		}
+		_, ok := c.Get("idle")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("expire after write and access test", t, func() {
+		c, err := gcache.New[string, int]("test_write_access",
+			gcache.WithShards(1),
+			gcache.WithExpiration(100*time.Millisecond),
+			gcache.WithExpirationMode(gcache.ExpireAfterWrite|gcache.ExpireAfterAccess),
+			gcache.WithAccessExpiration(40*time.Millisecond),
+			gcache.WithCleanInterval(0))
+		So(err, ShouldBeNil)
+		defer c.Close(context.Background())
+		c.Set("read", 1)
+		c.Set("idle", 2)
+		for i := 0; i < 3; i++ {
+			time.Sleep(20 * time.Millisecond)
+			_, ok := c.Get("read")
+			So(ok, ShouldBeTrue)
+		}
+		_, ok := c.Get("idle")
+		So(ok, ShouldBeFalse)
+		time.Sleep(60 * time.Millisecond)
+		_, ok = c.Get("read")
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func Test_ExpireOnRead(t *testing.T) {
+	Convey("expired entries are removed on read test", t, func() {
+		for _, strategy := range []string{"LRU", "LFU", "ARC", "TinyLFU"} {
+			expired := make(chan interface{}, 4)
+			c, err := gcache.NewGCache("test_expire_on_read",
+				gcache.WithShards(1),
+				gcache.WithEvictStrategy(strategy),
+				gcache.WithCleanInterval(0),
+				gcache.WithOnRemoveCallbackFunc(func(key interface{}, value interface{}, reason cache.RemoveReason) {
+					if reason == cache.Expired {
+						expired <- key
+					}
+				}))
+			So(err, ShouldBeNil)
+			c.SetWithTTL("get", 1, time.Millisecond)
+			c.SetWithTTL("contains", 2, time.Millisecond)
+			c.SetWithTTL("count", 3, time.Millisecond)
+			c.Set("live", 4)
+			time.Sleep(5 * time.Millisecond)
+
+			_, ok := c.Get("get")
+			So(ok, ShouldBeFalse)
+			So(c.Contains("contains"), ShouldBeFalse)
+			So(c.Count(), ShouldEqual, 1)
+			So(c.Stats().ExpiredOnRead, ShouldEqual, 1)
+			So(c.Close(context.Background()), ShouldBeNil)
+			So(len(expired), ShouldEqual, 3)
+			So(<-expired, ShouldEqual, "get")
+			So(<-expired, ShouldEqual, "contains")
+		}
+	})
+}
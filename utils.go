@@ -18,17 +18,22 @@ func isPowerOfTwo(number int) bool {
 
 func PrintPanicStack(extras ...interface{}) {
 	if x := recover(); x != nil {
-		l.Errorf("%v", x)
-		i := 0
-		funcName, file, line, ok := runtime.Caller(i)
-		for ok {
-			l.Errorf("frame %v:[func:%v,file:%v,line:%v]\n", i, runtime.FuncForPC(funcName).Name(), file, line)
-			i++
-			funcName, file, line, ok = runtime.Caller(i)
-		}
+		logPanic(l, x, extras...)
+	}
+}
+
+// logPanic logs the recovered panic x with the stack, the deferred function must call recover itself.
+func logPanic(logger Logger, x interface{}, extras ...interface{}) {
+	logger.Errorf("%v", x)
+	i := 0
+	funcName, file, line, ok := runtime.Caller(i)
+	for ok {
+		logger.Errorf("frame %v:[func:%v,file:%v,line:%v]\n", i, runtime.FuncForPC(funcName).Name(), file, line)
+		i++
+		funcName, file, line, ok = runtime.Caller(i)
+	}
 
-		for k := range extras {
-			l.Errorf("EXRAS#%v DATA:%v\n", k, spew.Sdump(extras[k]))
-		}
+	for k := range extras {
+		logger.Errorf("EXRAS#%v DATA:%v\n", k, spew.Sdump(extras[k]))
 	}
 }